@@ -1,8 +1,16 @@
 package main
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
 	"log"
+	"os"
+	"strconv"
+	"text/tabwriter"
 
+	"github.com/dihanto/gosnap/database"
 	"github.com/dihanto/gosnap/internal/app/config"
 	"github.com/dihanto/gosnap/internal/app/controller"
 	"github.com/dihanto/gosnap/internal/app/exception"
@@ -10,6 +18,7 @@ import (
 	"github.com/dihanto/gosnap/internal/app/middleware"
 	"github.com/dihanto/gosnap/internal/app/repository"
 	"github.com/dihanto/gosnap/internal/app/usecase"
+	"github.com/dihanto/gosnap/internal/app/worker"
 	"github.com/go-playground/validator/v10"
 	"github.com/labstack/echo/v4"
 	echoMiddleware "github.com/labstack/echo/v4/middleware"
@@ -22,9 +31,19 @@ func main() {
 	serverHost := viper.GetString("server.host")
 	serverPort := viper.GetString("server.port")
 	usecaseTimeout := viper.GetInt("usecase.timeout")
+	uploadMaxBytes := viper.GetInt64("upload.maxBytes")
+	uploadAllowedTypes := viper.GetStringSlice("upload.allowedTypes")
+	feedPageSize := viper.GetInt("feed.pageSize")
+	imagingWorkers := viper.GetInt("imaging.workers")
+	imagingQueueSize := viper.GetInt("imaging.queueSize")
+	refreshTokenTTL := viper.GetDuration("jwt.refreshTtl")
+	usernameCooldown := viper.GetDuration("user.usernameCooldown")
+	commentMaxDepth := viper.GetInt("comment.maxDepth")
+	commentMaxPinned := viper.GetInt("comment.maxPinned")
 
 	router := echo.New()
 	router.HTTPErrorHandler = exception.ErrorHandler
+	router.Use(echoMiddleware.RequestID())
 	router.Use(echoMiddleware.CORSWithConfig(echoMiddleware.CORSConfig{
 		AllowOrigins: []string{"*"},
 		AllowMethods: []string{"GET", "POST", "PUT", "DELETE"},
@@ -38,50 +57,183 @@ func main() {
 	if err != nil {
 		log.Fatalln(err)
 	}
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		err = runMigrate(context.Background(), databaseConnection, os.Args[2:])
+		if err != nil {
+			log.Fatalln(err)
+		}
+		return
+	}
+	if viper.GetBool("database.migrateOnStart") {
+		migrator, err := database.NewMigrator(databaseConnection)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		applied, err := migrator.Up(context.Background())
+		if err != nil {
+			log.Fatalln(err)
+		}
+		log.Printf("applied %d migrations", len(applied))
+	}
+	blobStore, err := config.InitBlobStore()
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if viper.GetString("storage.driver") == "local" {
+		router.Group(viper.GetString("storage.local.route"), middleware.HidePrivateObjects).Static("/", viper.GetString("storage.local.root"))
+	}
+
+	tokenManager, err := config.InitTokenManager()
+	if err != nil {
+		log.Fatalln(err)
+	}
+	middleware.InitAuth(tokenManager)
+
 	validate := validator.New()
-	validate.RegisterValidation("email_uniq", helper.ValidateEmailUniq)
-	validate.RegisterValidation("username_uniq", helper.ValidateUsernameUniq)
-	validate.RegisterValidation("likes", helper.ValidateOneUserOneLike)
-	validate.RegisterValidation("follow", helper.ValidateUserNotFollowTwice)
+	err = exception.InitValidation(validate)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	err = helper.NewDatabaseValidator(databaseConnection).Register(validate)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	txManager := helper.NewTxManager(databaseConnection)
+	photoRepository := repository.NewPhotoRepository(databaseConnection)
+	photoVariantWorker := worker.NewPhotoVariantWorker(photoRepository, blobStore, usecaseTimeout, imagingQueueSize)
+	notificationRepository := repository.NewNotificationRepository(databaseConnection)
+	notificationUsecase := usecase.NewNotificationUsecase(notificationRepository, photoRepository, txManager, validate, usecaseTimeout)
+
+	if len(os.Args) > 1 && os.Args[1] == "migrate-photos" {
+		photoUsecase := usecase.NewPhotoUsecase(photoRepository, blobStore, photoVariantWorker, notificationUsecase, txManager, validate, usecaseTimeout, uploadAllowedTypes, feedPageSize)
+		migrated, err := photoUsecase.MigrateLegacyPhotos(context.Background())
+		log.Printf("moved %d photos into blob storage", migrated)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		return
+	}
+
+	controller.NewJWKSController(tokenManager, router)
+
+	var userUsecase usecase.UserUsecase
+	var photoUsecase usecase.PhotoUsecase
+	var commentUsecase usecase.CommentUsecase
 
 	{
 		userRepository := repository.NewUserRepository(databaseConnection)
-		userUsecase := usecase.NewUserUsecase(userRepository, validate, usecaseTimeout)
-		controller.NewUserController(userUsecase, router)
+		userUsecase = usecase.NewUserUsecase(userRepository, photoRepository, txManager, validate, usecaseTimeout, usernameCooldown)
+		sessionRepository := repository.NewSessionRepository(databaseConnection)
+		sessionUsecase := usecase.NewSessionUsecase(sessionRepository, tokenManager, validate, usecaseTimeout, refreshTokenTTL)
+		controller.NewUserController(userUsecase, sessionUsecase, router)
 	}
 
 	{
-		photoRepository := repository.NewPhotoRepository(databaseConnection)
-		photoUsecase := usecase.NewPhotoUsecase(photoRepository, validate, usecaseTimeout)
-		controller.NewPhotoController(photoUsecase, router)
+		photoVariantWorker.Start(imagingWorkers)
+		photoUsecase = usecase.NewPhotoUsecase(photoRepository, blobStore, photoVariantWorker, notificationUsecase, txManager, validate, usecaseTimeout, uploadAllowedTypes, feedPageSize)
+		controller.NewPhotoController(photoUsecase, router, uploadMaxBytes)
 	}
 
 	{
 		commentRepository := repository.NewCommentRepository(databaseConnection)
-		commentUsecase := usecase.NewCommentUsecase(commentRepository, validate, usecaseTimeout)
+		commentUsecase = usecase.NewCommentUsecase(commentRepository, photoRepository, blobStore, notificationUsecase, txManager, validate, usecaseTimeout, commentMaxDepth, commentMaxPinned)
 		controller.NewCommentController(commentUsecase, router)
 	}
 
 	{
 		socialMediaRepository := repository.NewSocialMediaRepository(databaseConnection)
-		socialMediaUsecase := usecase.NewSocialMediaUsecase(socialMediaRepository, validate, usecaseTimeout)
+		socialMediaUsecase := usecase.NewSocialMediaUsecase(socialMediaRepository, txManager, validate, usecaseTimeout)
 		controller.NewSocialMediaController(socialMediaUsecase, router)
 	}
 
 	{
 		followRepository := repository.NewFollowRepositoryImpl(databaseConnection)
-		followUsecase := usecase.NewFollowUsecaseImpl(followRepository, validate, usecaseTimeout)
+		followUsecase := usecase.NewFollowUsecaseImpl(followRepository, notificationUsecase, validate, usecaseTimeout)
 		controller.NewFollowControllerImpl(followUsecase, router)
 	}
 	{
 		likeRepository := repository.NewLikeRepository(databaseConnection)
-		likeUsecase := usecase.NewLikeUsecaseImpl(likeRepository, validate, usecaseTimeout)
+		likeUsecase := usecase.NewLikeUsecaseImpl(likeRepository, notificationUsecase, validate, usecaseTimeout)
 		controller.NewLikeController(likeUsecase, router)
 	}
 
+	controller.NewAdminController(photoUsecase, commentUsecase, userUsecase, router)
+	controller.NewSearchController(photoUsecase, userUsecase, router)
+	controller.NewNotificationController(notificationUsecase, router)
+
 	err = router.Start(serverHost + ":" + serverPort)
 	if err != nil {
 		log.Fatalln(err)
 	}
 
 }
+
+const migrateUsage = "usage: gosnap migrate up | down | status | to <version> | baseline <version>"
+
+// runMigrate handles the "migrate" subcommand.
+func runMigrate(ctx context.Context, databaseConnection *sql.DB, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	migrator, err := database.NewMigrator(databaseConnection)
+	if err != nil {
+		return err
+	}
+
+	var changed []database.Migration
+	switch args[0] {
+	case "up":
+		changed, err = migrator.Up(ctx)
+	case "down":
+		changed, err = migrator.Down(ctx)
+	case "to", "baseline":
+		if len(args) < 2 {
+			return errors.New(migrateUsage)
+		}
+		version, parseErr := strconv.ParseInt(args[1], 10, 64)
+		if parseErr != nil {
+			return fmt.Errorf("invalid migration version %q", args[1])
+		}
+		if args[0] == "to" {
+			changed, err = migrator.To(ctx, version)
+		} else {
+			changed, err = migrator.Baseline(ctx, version)
+		}
+	case "status":
+		return printMigrationStatus(ctx, migrator)
+	default:
+		return errors.New(migrateUsage)
+	}
+
+	for _, migration := range changed {
+		fmt.Printf("%s %d_%s\n", args[0], migration.Version, migration.Name)
+	}
+	if err == nil && len(changed) == 0 {
+		fmt.Println("nothing to migrate")
+	}
+	return err
+}
+
+func printMigrationStatus(ctx context.Context, migrator *database.Migrator) error {
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "VERSION\tNAME\tAPPLIED AT\t")
+	for _, status := range statuses {
+		appliedAt := "pending"
+		if status.Applied {
+			appliedAt = status.AppliedAt.Local().Format("2006-01-02 15:04:05")
+		}
+		if status.ChecksumMismatch {
+			appliedAt += " (changed since applied)"
+		}
+		fmt.Fprintf(writer, "%d\t%s\t%s\t\n", status.Version, status.Name, appliedAt)
+	}
+
+	return writer.Flush()
+}
@@ -1 +1 @@
-ALTER Table users ADD COLUMN age;
\ No newline at end of file
+ALTER TABLE users ADD COLUMN age INT;
\ No newline at end of file
@@ -0,0 +1,5 @@
+UPDATE photos SET photo_base64 = '' WHERE photo_base64 IS NULL;
+
+ALTER TABLE photos ALTER COLUMN photo_base64 SET NOT NULL;
+
+ALTER TABLE IF EXISTS photos DROP COLUMN photo_key;
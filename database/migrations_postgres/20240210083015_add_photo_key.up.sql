@@ -0,0 +1,3 @@
+ALTER TABLE photos ADD COLUMN photo_key VARCHAR(255);
+
+ALTER TABLE photos ALTER COLUMN photo_base64 DROP NOT NULL;
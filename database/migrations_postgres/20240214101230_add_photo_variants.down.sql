@@ -0,0 +1,3 @@
+ALTER TABLE IF EXISTS photos DROP COLUMN medium_key;
+
+ALTER TABLE IF EXISTS photos DROP COLUMN thumbnail_key;
@@ -0,0 +1,3 @@
+ALTER TABLE photos ADD COLUMN thumbnail_key VARCHAR(255);
+
+ALTER TABLE photos ADD COLUMN medium_key VARCHAR(255);
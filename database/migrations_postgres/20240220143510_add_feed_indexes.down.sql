@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS follower_details_follower_name_idx;
+
+DROP INDEX IF EXISTS photos_user_id_created_at_idx;
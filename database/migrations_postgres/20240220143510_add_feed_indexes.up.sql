@@ -0,0 +1,3 @@
+CREATE INDEX IF NOT EXISTS photos_user_id_created_at_idx ON photos (user_id, created_at DESC) WHERE deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS follower_details_follower_name_idx ON follower_details (follower_name);
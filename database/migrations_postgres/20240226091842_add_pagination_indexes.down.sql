@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS social_medias_created_at_id_idx;
+
+DROP INDEX IF EXISTS comments_created_at_id_idx;
+
+DROP INDEX IF EXISTS photos_created_at_id_idx;
@@ -0,0 +1,5 @@
+CREATE INDEX IF NOT EXISTS photos_created_at_id_idx ON photos (created_at DESC, id DESC) WHERE deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS comments_created_at_id_idx ON comments (created_at DESC, id DESC) WHERE deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS social_medias_created_at_id_idx ON social_medias (created_at DESC, id DESC) WHERE deleted_at IS NULL;
@@ -0,0 +1,13 @@
+CREATE TABLE refresh_tokens (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL,
+    family_id UUID NOT NULL,
+    token_hash VARCHAR(64) NOT NULL,
+    created_at INT NOT NULL,
+    expires_at INT NOT NULL,
+    revoked_at INT,
+    CONSTRAINT token_hash_uniq UNIQUE (token_hash),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE INDEX refresh_tokens_family_id_idx ON refresh_tokens (family_id);
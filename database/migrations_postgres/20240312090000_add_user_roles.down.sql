@@ -0,0 +1,5 @@
+ALTER TABLE IF EXISTS users DROP COLUMN suspended_at;
+
+ALTER TABLE IF EXISTS users DROP CONSTRAINT role_check;
+
+ALTER TABLE IF EXISTS users DROP COLUMN role;
@@ -0,0 +1,5 @@
+ALTER TABLE users ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'user';
+
+ALTER TABLE users ADD CONSTRAINT role_check CHECK (role IN ('user', 'moderator', 'admin'));
+
+ALTER TABLE users ADD COLUMN suspended_at INT;
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS followers_username_idx;
+
+ALTER TABLE IF EXISTS follower_details DROP CONSTRAINT IF EXISTS follower_details_uniq;
+
+DROP INDEX IF EXISTS likes_photo_id_idx;
+
+ALTER TABLE IF EXISTS like_details DROP CONSTRAINT IF EXISTS like_details_uniq;
@@ -0,0 +1,17 @@
+DELETE FROM like_details a USING like_details b
+WHERE a.ctid < b.ctid AND a.like_id = b.like_id AND a.user_id = b.user_id;
+
+UPDATE likes SET like_count = (SELECT COUNT(*) FROM like_details WHERE like_details.like_id = likes.id);
+
+ALTER TABLE like_details ADD CONSTRAINT like_details_uniq UNIQUE (like_id, user_id);
+
+CREATE INDEX IF NOT EXISTS likes_photo_id_idx ON likes (photo_id);
+
+DELETE FROM follower_details a USING follower_details b
+WHERE a.ctid < b.ctid AND a.follow_id = b.follow_id AND a.follower_name = b.follower_name;
+
+UPDATE followers SET follower_count = (SELECT COUNT(*) FROM follower_details WHERE follower_details.follow_id = followers.id);
+
+ALTER TABLE follower_details ADD CONSTRAINT follower_details_uniq UNIQUE (follow_id, follower_name);
+
+CREATE INDEX IF NOT EXISTS followers_username_idx ON followers (username);
@@ -0,0 +1,68 @@
+CREATE TABLE likes (
+    id SERIAL PRIMARY KEY,
+    like_count INT NOT NULL DEFAULT 0,
+    photo_id INT,
+    FOREIGN KEY (photo_id) REFERENCES photos(id)
+);
+
+CREATE TABLE like_details (
+    like_id int,
+    user_id UUID,
+    liked_at INT NOT NULL DEFAULT 0,
+    FOREIGN KEY (like_id) REFERENCES likes(id),
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    CONSTRAINT like_details_uniq UNIQUE (like_id, user_id)
+);
+
+CREATE INDEX likes_photo_id_idx ON likes (photo_id);
+
+CREATE TABLE followers (
+    id SERIAL PRIMARY KEY,
+    follower_count INT NOT NULL DEFAULT 0,
+    user_id UUID,
+    username VARCHAR(100),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE TABLE follower_details (
+    follow_id int,
+    follower_name VARCHAR(100),
+    FOREIGN KEY (follow_id) REFERENCES followers(id),
+    CONSTRAINT follower_details_uniq UNIQUE (follow_id, follower_name)
+);
+
+CREATE INDEX follower_details_follower_name_idx ON follower_details (follower_name);
+
+CREATE INDEX followers_username_idx ON followers (username);
+
+INSERT INTO likes (photo_id, like_count) SELECT id, like_count FROM photos;
+
+INSERT INTO like_details (like_id, user_id, liked_at)
+SELECT likes.id, photo_likes.user_id, photo_likes.created_at
+FROM photo_likes JOIN likes ON likes.photo_id = photo_likes.photo_id;
+
+INSERT INTO followers (user_id, username, follower_count) SELECT id, username, follower_count FROM users;
+
+INSERT INTO follower_details (follow_id, follower_name)
+SELECT followers.id, follower.username
+FROM follows
+JOIN followers ON followers.user_id = follows.followee_id
+JOIN users follower ON follower.id = follows.follower_id;
+
+DROP TRIGGER IF EXISTS follows_count ON follows;
+
+DROP FUNCTION IF EXISTS follows_count();
+
+DROP TRIGGER IF EXISTS photo_likes_count ON photo_likes;
+
+DROP FUNCTION IF EXISTS photo_likes_count();
+
+ALTER TABLE users DROP COLUMN following_count;
+
+ALTER TABLE users DROP COLUMN follower_count;
+
+ALTER TABLE photos DROP COLUMN like_count;
+
+DROP TABLE follows;
+
+DROP TABLE photo_likes;
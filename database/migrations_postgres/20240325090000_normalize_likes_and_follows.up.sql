@@ -0,0 +1,89 @@
+CREATE TABLE photo_likes (
+    photo_id INT NOT NULL,
+    user_id UUID NOT NULL,
+    created_at INT NOT NULL,
+    PRIMARY KEY (photo_id, user_id),
+    FOREIGN KEY (photo_id) REFERENCES photos(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX photo_likes_user_id_idx ON photo_likes (user_id);
+
+CREATE TABLE follows (
+    follower_id UUID NOT NULL,
+    followee_id UUID NOT NULL,
+    created_at INT NOT NULL,
+    PRIMARY KEY (follower_id, followee_id),
+    FOREIGN KEY (follower_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (followee_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT follows_not_self CHECK (follower_id <> followee_id)
+);
+
+CREATE INDEX follows_followee_id_idx ON follows (followee_id, follower_id);
+
+INSERT INTO photo_likes (photo_id, user_id, created_at)
+SELECT likes.photo_id, like_details.user_id, MIN(like_details.liked_at)
+FROM like_details
+JOIN likes ON likes.id = like_details.like_id
+JOIN photos ON photos.id = likes.photo_id
+JOIN users ON users.id = like_details.user_id
+GROUP BY likes.photo_id, like_details.user_id;
+
+INSERT INTO follows (follower_id, followee_id, created_at)
+SELECT DISTINCT follower.id, followers.user_id, EXTRACT(EPOCH FROM now())::INT
+FROM follower_details
+JOIN followers ON followers.id = follower_details.follow_id
+JOIN users follower ON follower.username = follower_details.follower_name
+JOIN users followee ON followee.id = followers.user_id
+WHERE follower.id <> followers.user_id;
+
+-- counts are kept next to the rows they describe and maintained by triggers
+ALTER TABLE photos ADD COLUMN like_count INT NOT NULL DEFAULT 0;
+
+ALTER TABLE users ADD COLUMN follower_count INT NOT NULL DEFAULT 0;
+
+ALTER TABLE users ADD COLUMN following_count INT NOT NULL DEFAULT 0;
+
+UPDATE photos SET like_count = (SELECT COUNT(*) FROM photo_likes WHERE photo_likes.photo_id = photos.id);
+
+UPDATE users SET
+    follower_count = (SELECT COUNT(*) FROM follows WHERE follows.followee_id = users.id),
+    following_count = (SELECT COUNT(*) FROM follows WHERE follows.follower_id = users.id);
+
+CREATE FUNCTION photo_likes_count() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        UPDATE photos SET like_count = like_count + 1 WHERE id = NEW.photo_id;
+    ELSE
+        UPDATE photos SET like_count = like_count - 1 WHERE id = OLD.photo_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER photo_likes_count AFTER INSERT OR DELETE ON photo_likes
+    FOR EACH ROW EXECUTE FUNCTION photo_likes_count();
+
+CREATE FUNCTION follows_count() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        UPDATE users SET follower_count = follower_count + 1 WHERE id = NEW.followee_id;
+        UPDATE users SET following_count = following_count + 1 WHERE id = NEW.follower_id;
+    ELSE
+        UPDATE users SET follower_count = follower_count - 1 WHERE id = OLD.followee_id;
+        UPDATE users SET following_count = following_count - 1 WHERE id = OLD.follower_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER follows_count AFTER INSERT OR DELETE ON follows
+    FOR EACH ROW EXECUTE FUNCTION follows_count();
+
+DROP TABLE like_details;
+
+DROP TABLE likes;
+
+DROP TABLE follower_details;
+
+DROP TABLE followers;
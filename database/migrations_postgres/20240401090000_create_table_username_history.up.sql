@@ -0,0 +1,11 @@
+CREATE TABLE username_history (
+    id SERIAL PRIMARY KEY,
+    user_id UUID NOT NULL,
+    username VARCHAR(100) NOT NULL,
+    changed_at INT NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX username_history_username_idx ON username_history (username, changed_at DESC);
+
+CREATE INDEX username_history_user_id_idx ON username_history (user_id);
@@ -0,0 +1,54 @@
+ALTER TABLE username_history
+    ALTER COLUMN changed_at DROP DEFAULT,
+    ALTER COLUMN changed_at TYPE INT USING EXTRACT(EPOCH FROM changed_at)::INT;
+
+ALTER TABLE follows
+    ALTER COLUMN created_at DROP DEFAULT,
+    ALTER COLUMN created_at TYPE INT USING EXTRACT(EPOCH FROM created_at)::INT;
+
+ALTER TABLE photo_likes
+    ALTER COLUMN created_at DROP DEFAULT,
+    ALTER COLUMN created_at TYPE INT USING EXTRACT(EPOCH FROM created_at)::INT;
+
+ALTER TABLE refresh_tokens
+    ALTER COLUMN created_at DROP DEFAULT,
+    ALTER COLUMN created_at TYPE INT USING EXTRACT(EPOCH FROM created_at)::INT,
+    ALTER COLUMN expires_at TYPE INT USING EXTRACT(EPOCH FROM expires_at)::INT,
+    ALTER COLUMN revoked_at TYPE INT USING EXTRACT(EPOCH FROM revoked_at)::INT;
+
+ALTER TABLE social_medias
+    ALTER COLUMN created_at DROP DEFAULT,
+    ALTER COLUMN updated_at DROP DEFAULT,
+    ALTER COLUMN created_at TYPE INT USING EXTRACT(EPOCH FROM created_at)::INT,
+    ALTER COLUMN updated_at TYPE INT USING EXTRACT(EPOCH FROM updated_at)::INT,
+    ALTER COLUMN deleted_at TYPE INT USING EXTRACT(EPOCH FROM deleted_at)::INT,
+    ALTER COLUMN created_at SET DEFAULT 0,
+    ALTER COLUMN updated_at SET DEFAULT 0;
+
+ALTER TABLE comments
+    ALTER COLUMN created_at DROP DEFAULT,
+    ALTER COLUMN updated_at DROP DEFAULT,
+    ALTER COLUMN created_at TYPE INT USING EXTRACT(EPOCH FROM created_at)::INT,
+    ALTER COLUMN updated_at TYPE INT USING EXTRACT(EPOCH FROM updated_at)::INT,
+    ALTER COLUMN deleted_at TYPE INT USING EXTRACT(EPOCH FROM deleted_at)::INT,
+    ALTER COLUMN created_at SET DEFAULT 0,
+    ALTER COLUMN updated_at SET DEFAULT 0;
+
+ALTER TABLE photos
+    ALTER COLUMN created_at DROP DEFAULT,
+    ALTER COLUMN updated_at DROP DEFAULT,
+    ALTER COLUMN created_at TYPE INT USING EXTRACT(EPOCH FROM created_at)::INT,
+    ALTER COLUMN updated_at TYPE INT USING EXTRACT(EPOCH FROM updated_at)::INT,
+    ALTER COLUMN deleted_at TYPE INT USING EXTRACT(EPOCH FROM deleted_at)::INT,
+    ALTER COLUMN created_at SET DEFAULT 0,
+    ALTER COLUMN updated_at SET DEFAULT 0;
+
+ALTER TABLE users
+    ALTER COLUMN created_at DROP DEFAULT,
+    ALTER COLUMN created_at DROP NOT NULL,
+    ALTER COLUMN updated_at DROP DEFAULT,
+    ALTER COLUMN updated_at DROP NOT NULL,
+    ALTER COLUMN created_at TYPE INT USING EXTRACT(EPOCH FROM created_at)::INT,
+    ALTER COLUMN updated_at TYPE INT USING EXTRACT(EPOCH FROM updated_at)::INT,
+    ALTER COLUMN deleted_at TYPE INT USING EXTRACT(EPOCH FROM deleted_at)::INT,
+    ALTER COLUMN suspended_at TYPE INT USING EXTRACT(EPOCH FROM suspended_at)::INT;
@@ -0,0 +1,63 @@
+-- unix seconds in INT columns overflow in 2038; 0 was used for "not set" in updated_at
+ALTER TABLE users
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ USING to_timestamp(COALESCE(created_at, 0)),
+    ALTER COLUMN updated_at TYPE TIMESTAMPTZ USING to_timestamp(COALESCE(NULLIF(updated_at, 0), created_at, 0)),
+    ALTER COLUMN deleted_at TYPE TIMESTAMPTZ USING to_timestamp(NULLIF(deleted_at, 0)),
+    ALTER COLUMN suspended_at TYPE TIMESTAMPTZ USING to_timestamp(NULLIF(suspended_at, 0));
+
+ALTER TABLE users
+    ALTER COLUMN created_at SET DEFAULT now(),
+    ALTER COLUMN created_at SET NOT NULL,
+    ALTER COLUMN updated_at SET DEFAULT now(),
+    ALTER COLUMN updated_at SET NOT NULL;
+
+ALTER TABLE photos
+    ALTER COLUMN created_at DROP DEFAULT,
+    ALTER COLUMN updated_at DROP DEFAULT;
+
+ALTER TABLE photos
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ USING to_timestamp(created_at),
+    ALTER COLUMN updated_at TYPE TIMESTAMPTZ USING to_timestamp(COALESCE(NULLIF(updated_at, 0), created_at)),
+    ALTER COLUMN deleted_at TYPE TIMESTAMPTZ USING to_timestamp(NULLIF(deleted_at, 0)),
+    ALTER COLUMN created_at SET DEFAULT now(),
+    ALTER COLUMN updated_at SET DEFAULT now();
+
+ALTER TABLE comments
+    ALTER COLUMN created_at DROP DEFAULT,
+    ALTER COLUMN updated_at DROP DEFAULT;
+
+ALTER TABLE comments
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ USING to_timestamp(created_at),
+    ALTER COLUMN updated_at TYPE TIMESTAMPTZ USING to_timestamp(COALESCE(NULLIF(updated_at, 0), created_at)),
+    ALTER COLUMN deleted_at TYPE TIMESTAMPTZ USING to_timestamp(NULLIF(deleted_at, 0)),
+    ALTER COLUMN created_at SET DEFAULT now(),
+    ALTER COLUMN updated_at SET DEFAULT now();
+
+ALTER TABLE social_medias
+    ALTER COLUMN created_at DROP DEFAULT,
+    ALTER COLUMN updated_at DROP DEFAULT;
+
+ALTER TABLE social_medias
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ USING to_timestamp(created_at),
+    ALTER COLUMN updated_at TYPE TIMESTAMPTZ USING to_timestamp(COALESCE(NULLIF(updated_at, 0), created_at)),
+    ALTER COLUMN deleted_at TYPE TIMESTAMPTZ USING to_timestamp(NULLIF(deleted_at, 0)),
+    ALTER COLUMN created_at SET DEFAULT now(),
+    ALTER COLUMN updated_at SET DEFAULT now();
+
+ALTER TABLE refresh_tokens
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ USING to_timestamp(created_at),
+    ALTER COLUMN expires_at TYPE TIMESTAMPTZ USING to_timestamp(expires_at),
+    ALTER COLUMN revoked_at TYPE TIMESTAMPTZ USING to_timestamp(NULLIF(revoked_at, 0)),
+    ALTER COLUMN created_at SET DEFAULT now();
+
+ALTER TABLE photo_likes
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ USING to_timestamp(created_at),
+    ALTER COLUMN created_at SET DEFAULT now();
+
+ALTER TABLE follows
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ USING to_timestamp(created_at),
+    ALTER COLUMN created_at SET DEFAULT now();
+
+ALTER TABLE username_history
+    ALTER COLUMN changed_at TYPE TIMESTAMPTZ USING to_timestamp(changed_at),
+    ALTER COLUMN changed_at SET DEFAULT now();
@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS comments_parent_id_idx;
+
+DROP INDEX IF EXISTS comments_photo_id_top_level_idx;
+
+ALTER TABLE IF EXISTS comments DROP CONSTRAINT comments_depth_check;
+
+ALTER TABLE IF EXISTS comments DROP COLUMN depth;
+
+-- replies lose their parent and become comments on the photo
+ALTER TABLE IF EXISTS comments DROP COLUMN parent_id;
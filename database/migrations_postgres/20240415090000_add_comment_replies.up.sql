@@ -0,0 +1,10 @@
+ALTER TABLE comments ADD COLUMN parent_id INT REFERENCES comments(id) ON DELETE CASCADE;
+
+-- 0 for comments on the photo itself, one more than the parent for replies
+ALTER TABLE comments ADD COLUMN depth SMALLINT NOT NULL DEFAULT 0;
+
+ALTER TABLE comments ADD CONSTRAINT comments_depth_check CHECK ((parent_id IS NULL) = (depth = 0));
+
+CREATE INDEX comments_photo_id_top_level_idx ON comments (photo_id, created_at DESC, id DESC) WHERE parent_id IS NULL AND deleted_at IS NULL;
+
+CREATE INDEX comments_parent_id_idx ON comments (parent_id, created_at, id) WHERE deleted_at IS NULL;
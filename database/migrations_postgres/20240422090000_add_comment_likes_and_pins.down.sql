@@ -0,0 +1,13 @@
+DROP INDEX IF EXISTS comments_photo_id_pinned_idx;
+
+ALTER TABLE IF EXISTS comments DROP CONSTRAINT comments_pinned_top_level;
+
+ALTER TABLE IF EXISTS comments DROP COLUMN pinned_at;
+
+DROP TRIGGER IF EXISTS comment_likes_count ON comment_likes;
+
+DROP FUNCTION IF EXISTS comment_likes_count();
+
+ALTER TABLE IF EXISTS comments DROP COLUMN like_count;
+
+DROP TABLE IF EXISTS comment_likes;
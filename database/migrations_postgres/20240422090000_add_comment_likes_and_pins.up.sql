@@ -0,0 +1,33 @@
+CREATE TABLE comment_likes (
+    comment_id INT NOT NULL,
+    user_id UUID NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (comment_id, user_id),
+    FOREIGN KEY (comment_id) REFERENCES comments(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX comment_likes_user_id_idx ON comment_likes (user_id);
+
+ALTER TABLE comments ADD COLUMN like_count INT NOT NULL DEFAULT 0;
+
+CREATE FUNCTION comment_likes_count() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        UPDATE comments SET like_count = like_count + 1 WHERE id = NEW.comment_id;
+    ELSE
+        UPDATE comments SET like_count = like_count - 1 WHERE id = OLD.comment_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER comment_likes_count AFTER INSERT OR DELETE ON comment_likes
+    FOR EACH ROW EXECUTE FUNCTION comment_likes_count();
+
+-- set while the photo owner keeps the comment at the top of the photo's comments
+ALTER TABLE comments ADD COLUMN pinned_at TIMESTAMPTZ;
+
+ALTER TABLE comments ADD CONSTRAINT comments_pinned_top_level CHECK (pinned_at IS NULL OR parent_id IS NULL);
+
+CREATE INDEX comments_photo_id_pinned_idx ON comments (photo_id, pinned_at) WHERE pinned_at IS NOT NULL AND deleted_at IS NULL;
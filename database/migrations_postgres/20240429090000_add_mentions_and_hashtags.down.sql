@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS comment_mentions;
+
+DROP TABLE IF EXISTS comment_hashtags;
+
+DROP TABLE IF EXISTS photo_mentions;
+
+DROP TABLE IF EXISTS photo_hashtags;
+
+DROP TABLE IF EXISTS hashtags;
@@ -0,0 +1,55 @@
+CREATE TABLE hashtags (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT hashtags_name_uniq UNIQUE (name)
+);
+
+-- offsets count characters of the caption or message, end excluded, and cover the # or @
+CREATE TABLE photo_hashtags (
+    photo_id INT NOT NULL,
+    hashtag_id INT NOT NULL,
+    start_offset INT NOT NULL,
+    end_offset INT NOT NULL,
+    PRIMARY KEY (photo_id, start_offset),
+    FOREIGN KEY (photo_id) REFERENCES photos(id) ON DELETE CASCADE,
+    FOREIGN KEY (hashtag_id) REFERENCES hashtags(id) ON DELETE CASCADE
+);
+
+CREATE INDEX photo_hashtags_hashtag_id_idx ON photo_hashtags (hashtag_id, photo_id);
+
+CREATE TABLE photo_mentions (
+    photo_id INT NOT NULL,
+    user_id UUID NOT NULL,
+    start_offset INT NOT NULL,
+    end_offset INT NOT NULL,
+    PRIMARY KEY (photo_id, start_offset),
+    FOREIGN KEY (photo_id) REFERENCES photos(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX photo_mentions_user_id_idx ON photo_mentions (user_id, photo_id);
+
+CREATE TABLE comment_hashtags (
+    comment_id INT NOT NULL,
+    hashtag_id INT NOT NULL,
+    start_offset INT NOT NULL,
+    end_offset INT NOT NULL,
+    PRIMARY KEY (comment_id, start_offset),
+    FOREIGN KEY (comment_id) REFERENCES comments(id) ON DELETE CASCADE,
+    FOREIGN KEY (hashtag_id) REFERENCES hashtags(id) ON DELETE CASCADE
+);
+
+CREATE INDEX comment_hashtags_hashtag_id_idx ON comment_hashtags (hashtag_id, comment_id);
+
+CREATE TABLE comment_mentions (
+    comment_id INT NOT NULL,
+    user_id UUID NOT NULL,
+    start_offset INT NOT NULL,
+    end_offset INT NOT NULL,
+    PRIMARY KEY (comment_id, start_offset),
+    FOREIGN KEY (comment_id) REFERENCES comments(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX comment_mentions_user_id_idx ON comment_mentions (user_id, comment_id);
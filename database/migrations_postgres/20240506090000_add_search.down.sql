@@ -0,0 +1,13 @@
+DROP INDEX IF EXISTS hashtags_name_trgm_idx;
+
+DROP INDEX IF EXISTS photos_search_vector_idx;
+
+ALTER TABLE IF EXISTS photos DROP COLUMN search_vector;
+
+DROP INDEX IF EXISTS users_username_trgm_idx;
+
+DROP INDEX IF EXISTS users_search_vector_idx;
+
+ALTER TABLE IF EXISTS users DROP COLUMN search_vector;
+
+DROP EXTENSION IF EXISTS pg_trgm;
@@ -0,0 +1,17 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- 'simple' keeps names as typed instead of stemming them as English words
+ALTER TABLE users ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (to_tsvector('simple', username || ' ' || name)) STORED;
+
+CREATE INDEX users_search_vector_idx ON users USING GIN (search_vector) WHERE deleted_at IS NULL;
+
+CREATE INDEX users_username_trgm_idx ON users USING GIN (username gin_trgm_ops) WHERE deleted_at IS NULL;
+
+-- titles rank above captions
+ALTER TABLE photos ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', title), 'A') || setweight(to_tsvector('english', caption), 'B')
+) STORED;
+
+CREATE INDEX photos_search_vector_idx ON photos USING GIN (search_vector) WHERE deleted_at IS NULL;
+
+CREATE INDEX hashtags_name_trgm_idx ON hashtags USING GIN (name gin_trgm_ops);
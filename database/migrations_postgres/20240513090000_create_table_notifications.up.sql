@@ -0,0 +1,40 @@
+CREATE TABLE notifications (
+    id SERIAL PRIMARY KEY,
+    user_id UUID NOT NULL,
+    type VARCHAR(20) NOT NULL,
+    photo_id INT,
+    comment_id INT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    read_at TIMESTAMPTZ,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (photo_id) REFERENCES photos(id) ON DELETE CASCADE,
+    FOREIGN KEY (comment_id) REFERENCES comments(id) ON DELETE CASCADE,
+    CONSTRAINT notifications_type_check CHECK (type IN ('like', 'comment', 'follow', 'mention'))
+);
+
+-- a user has at most one unread notification per type and photo or comment, which
+-- later events are coalesced into
+CREATE UNIQUE INDEX notifications_unread_uniq ON notifications (user_id, type, COALESCE(photo_id, 0), COALESCE(comment_id, 0)) WHERE read_at IS NULL;
+
+CREATE INDEX notifications_user_id_idx ON notifications (user_id, updated_at DESC, id DESC);
+
+CREATE TABLE notification_actors (
+    notification_id INT NOT NULL,
+    actor_id UUID NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (notification_id, actor_id),
+    FOREIGN KEY (notification_id) REFERENCES notifications(id) ON DELETE CASCADE,
+    FOREIGN KEY (actor_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- users without a row get every notification
+CREATE TABLE notification_preferences (
+    user_id UUID PRIMARY KEY,
+    likes BOOLEAN NOT NULL DEFAULT true,
+    comments BOOLEAN NOT NULL DEFAULT true,
+    follows BOOLEAN NOT NULL DEFAULT true,
+    mentions BOOLEAN NOT NULL DEFAULT true,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
@@ -0,0 +1 @@
+ALTER TABLE IF EXISTS photos DROP COLUMN variants_failed_at;
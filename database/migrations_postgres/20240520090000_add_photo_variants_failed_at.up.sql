@@ -0,0 +1,2 @@
+-- photos whose upload can't be rendered are not retried on every start
+ALTER TABLE photos ADD COLUMN variants_failed_at TIMESTAMPTZ;
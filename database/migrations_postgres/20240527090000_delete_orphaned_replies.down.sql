@@ -0,0 +1,2 @@
+-- the replies deleted by the up migration are indistinguishable from ones deleted by users, so they stay deleted
+SELECT 1;
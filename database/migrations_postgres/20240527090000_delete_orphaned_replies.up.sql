@@ -0,0 +1,9 @@
+-- deleting a comment now deletes its replies; do the same for comments deleted before
+WITH RECURSIVE orphans AS (
+    SELECT replies.id FROM comments replies JOIN comments parents ON parents.id = replies.parent_id
+    WHERE replies.deleted_at IS NULL AND parents.deleted_at IS NOT NULL
+    UNION
+    SELECT comments.id FROM comments JOIN orphans ON comments.parent_id = orphans.id
+    WHERE comments.deleted_at IS NULL
+)
+UPDATE comments SET deleted_at = now() WHERE id IN (SELECT id FROM orphans);
@@ -0,0 +1,364 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// migrationLockId is the postgres advisory lock key held while migrating, so two
+// instances starting at once don't apply the same migration twice.
+const migrationLockId = 7_211_994_201
+
+const createSchemaMigrations = `CREATE TABLE IF NOT EXISTS schema_migrations (
+	version BIGINT PRIMARY KEY,
+	name VARCHAR(255) NOT NULL,
+	checksum VARCHAR(64) NOT NULL,
+	applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+)`
+
+// MigrationStatus is a migration together with whether and when it was applied.
+// ChecksumMismatch is set when the applied up file differs from the embedded one.
+type MigrationStatus struct {
+	Migration
+	Applied          bool
+	AppliedAt        time.Time
+	ChecksumMismatch bool
+}
+
+type appliedMigration struct {
+	Checksum  string
+	AppliedAt time.Time
+}
+
+// Migrator applies migrations to a postgres database and records them in the
+// schema_migrations table.
+type Migrator struct {
+	Database   *sql.DB
+	Migrations []Migration
+}
+
+func NewMigrator(database *sql.DB) (*Migrator, error) {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		Database:   database,
+		Migrations: migrations,
+	}, nil
+}
+
+// Up applies every pending migration and returns the ones it applied.
+func (migrator *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var latest int64
+	if len(migrator.Migrations) > 0 {
+		latest = migrator.Migrations[len(migrator.Migrations)-1].Version
+	}
+	return migrator.To(ctx, latest)
+}
+
+// Down reverts the most recently applied migration, if any, and returns it.
+func (migrator *Migrator) Down(ctx context.Context) ([]Migration, error) {
+	var reverted []Migration
+	err := migrator.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := migrator.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for i := len(migrator.Migrations) - 1; i >= 0; i-- {
+			migration := migrator.Migrations[i]
+			if _, ok := applied[migration.Version]; !ok {
+				continue
+			}
+			err = migrator.revert(ctx, conn, migration)
+			if err != nil {
+				return err
+			}
+			reverted = append(reverted, migration)
+			return nil
+		}
+		return nil
+	})
+
+	return reverted, err
+}
+
+// To migrates up or down until version is the latest applied migration. Version 0
+// reverts every migration.
+func (migrator *Migrator) To(ctx context.Context, version int64) ([]Migration, error) {
+	if version != 0 && migrator.find(version) < 0 {
+		return nil, fmt.Errorf("unknown migration version %d", version)
+	}
+
+	var changed []Migration
+	err := migrator.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := migrator.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		err = migrator.verify(applied)
+		if err != nil {
+			return err
+		}
+
+		for i := len(migrator.Migrations) - 1; i >= 0; i-- {
+			migration := migrator.Migrations[i]
+			if _, ok := applied[migration.Version]; !ok || migration.Version <= version {
+				continue
+			}
+			err = migrator.revert(ctx, conn, migration)
+			if err != nil {
+				return err
+			}
+			changed = append(changed, migration)
+		}
+
+		for _, migration := range migrator.Migrations {
+			if _, ok := applied[migration.Version]; ok || migration.Version > version {
+				continue
+			}
+			err = migrator.apply(ctx, conn, migration)
+			if err != nil {
+				return err
+			}
+			changed = append(changed, migration)
+		}
+		return nil
+	})
+
+	return changed, err
+}
+
+// Baseline records every migration up to version as applied without running it,
+// for databases whose schema was set up by hand or by another migration tool.
+func (migrator *Migrator) Baseline(ctx context.Context, version int64) ([]Migration, error) {
+	if migrator.find(version) < 0 {
+		return nil, fmt.Errorf("unknown migration version %d", version)
+	}
+
+	var recorded []Migration
+	err := migrator.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := migrator.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range migrator.Migrations {
+			if _, ok := applied[migration.Version]; ok || migration.Version > version {
+				continue
+			}
+			query := "INSERT INTO schema_migrations (version, name, checksum) VALUES ($1, $2, $3)"
+			_, err = conn.ExecContext(ctx, query, migration.Version, migration.Name, migration.Checksum)
+			if err != nil {
+				return err
+			}
+			recorded = append(recorded, migration)
+		}
+		return nil
+	})
+
+	return recorded, err
+}
+
+// Status lists every known migration and whether it has been applied.
+func (migrator *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	var statuses []MigrationStatus
+	err := migrator.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := migrator.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range migrator.Migrations {
+			status := MigrationStatus{Migration: migration}
+			if record, ok := applied[migration.Version]; ok {
+				status.Applied = true
+				status.AppliedAt = record.AppliedAt
+				status.ChecksumMismatch = record.Checksum != migration.Checksum
+			}
+			statuses = append(statuses, status)
+		}
+		return nil
+	})
+
+	return statuses, err
+}
+
+// withLock runs fn on a single connection holding the migration advisory lock,
+// after making sure the schema_migrations table exists.
+func (migrator *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) (err error) {
+	conn, err := migrator.Database.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockId)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_, unlockErr := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockId)
+		if err == nil {
+			err = unlockErr
+		}
+	}()
+
+	err = migrator.adoptLegacyTable(ctx, conn)
+	if err != nil {
+		return err
+	}
+	_, err = conn.ExecContext(ctx, createSchemaMigrations)
+	if err != nil {
+		return err
+	}
+
+	return fn(conn)
+}
+
+// adoptLegacyTable takes over databases migrated with golang-migrate, whose
+// schema_migrations table only holds the current version and a dirty flag. The
+// old table is renamed and every migration up to its version is recorded as applied.
+func (migrator *Migrator) adoptLegacyTable(ctx context.Context, conn *sql.Conn) error {
+	var legacy bool
+	query := `SELECT EXISTS (
+		SELECT 1 FROM information_schema.columns
+		WHERE table_schema = current_schema() AND table_name = 'schema_migrations' AND column_name = 'dirty'
+	)`
+	err := conn.QueryRowContext(ctx, query).Scan(&legacy)
+	if err != nil || !legacy {
+		return err
+	}
+
+	var version int64
+	var dirty bool
+	err = conn.QueryRowContext(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	if dirty {
+		return fmt.Errorf("golang-migrate left schema_migrations dirty at version %d, fix the schema by hand first", version)
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, "ALTER TABLE schema_migrations RENAME TO schema_migrations_legacy")
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, createSchemaMigrations)
+	if err != nil {
+		return err
+	}
+	for _, migration := range migrator.Migrations {
+		if migration.Version > version {
+			break
+		}
+		query := "INSERT INTO schema_migrations (version, name, checksum) VALUES ($1, $2, $3)"
+		_, err = tx.ExecContext(ctx, query, migration.Version, migration.Name, migration.Checksum)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (migrator *Migrator) applied(ctx context.Context, conn *sql.Conn) (map[int64]appliedMigration, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version, checksum, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int64]appliedMigration{}
+	for rows.Next() {
+		var version int64
+		var record appliedMigration
+		err = rows.Scan(&version, &record.Checksum, &record.AppliedAt)
+		if err != nil {
+			return nil, err
+		}
+		applied[version] = record
+	}
+
+	return applied, rows.Err()
+}
+
+// verify refuses to migrate when an applied migration was edited afterwards or is
+// no longer known to this binary, since the schema can't be trusted to match.
+func (migrator *Migrator) verify(applied map[int64]appliedMigration) error {
+	var errs []error
+	for version, record := range applied {
+		i := migrator.find(version)
+		if i < 0 {
+			errs = append(errs, fmt.Errorf("migration %d is applied but not known to this build", version))
+			continue
+		}
+		if migrator.Migrations[i].Checksum != record.Checksum {
+			errs = append(errs, fmt.Errorf("migration %d_%s was changed after it was applied", version, migrator.Migrations[i].Name))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (migrator *Migrator) apply(ctx context.Context, conn *sql.Conn, migration Migration) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, migration.Up)
+	if err != nil {
+		return fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+	}
+	query := "INSERT INTO schema_migrations (version, name, checksum) VALUES ($1, $2, $3)"
+	_, err = tx.ExecContext(ctx, query, migration.Version, migration.Name, migration.Checksum)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (migrator *Migrator) revert(ctx context.Context, conn *sql.Conn, migration Migration) error {
+	if migration.Down == "" {
+		return fmt.Errorf("migration %d_%s has no down file", migration.Version, migration.Name)
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, migration.Down)
+	if err != nil {
+		return fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+	}
+	_, err = tx.ExecContext(ctx, "DELETE FROM schema_migrations WHERE version=$1", migration.Version)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (migrator *Migrator) find(version int64) int {
+	for i, migration := range migrator.Migrations {
+		if migration.Version == version {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,18 @@
+package auth
+
+// JWK is a public key in JSON Web Key (RFC 7517) form.
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyId     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	Modulus   string `json:"n,omitempty"`
+	Exponent  string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+}
+
+// JWKSet is the document served at /.well-known/jwks.json.
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
@@ -0,0 +1,108 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Key is a token signing key identified by the kid header of the tokens it signs.
+// SigningKey is nil for keys that are only kept around to verify older tokens.
+type Key struct {
+	Id              string
+	Method          jwt.SigningMethod
+	SigningKey      interface{}
+	VerificationKey interface{}
+}
+
+func NewHMACKey(id string, secret []byte) (Key, error) {
+	if len(secret) == 0 {
+		return Key{}, errors.New("jwt key " + id + " has an empty secret")
+	}
+
+	return Key{
+		Id:              id,
+		Method:          jwt.SigningMethodHS256,
+		SigningKey:      secret,
+		VerificationKey: secret,
+	}, nil
+}
+
+// NewPEMKey builds an RS256 or EdDSA key from PEM encoded keys. Either the private key,
+// from which the public key is derived, or only the public key has to be given.
+func NewPEMKey(id string, algorithm string, privatePEM []byte, publicPEM []byte) (Key, error) {
+	key := Key{Id: id}
+
+	switch algorithm {
+	case jwt.SigningMethodRS256.Alg():
+		key.Method = jwt.SigningMethodRS256
+		if len(privatePEM) > 0 {
+			privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(privatePEM)
+			if err != nil {
+				return Key{}, fmt.Errorf("jwt key %s: %w", id, err)
+			}
+			key.SigningKey = privateKey
+			key.VerificationKey = &privateKey.PublicKey
+		} else if len(publicPEM) > 0 {
+			publicKey, err := jwt.ParseRSAPublicKeyFromPEM(publicPEM)
+			if err != nil {
+				return Key{}, fmt.Errorf("jwt key %s: %w", id, err)
+			}
+			key.VerificationKey = publicKey
+		}
+	case jwt.SigningMethodEdDSA.Alg():
+		key.Method = jwt.SigningMethodEdDSA
+		if len(privatePEM) > 0 {
+			privateKey, err := jwt.ParseEdPrivateKeyFromPEM(privatePEM)
+			if err != nil {
+				return Key{}, fmt.Errorf("jwt key %s: %w", id, err)
+			}
+			key.SigningKey = privateKey
+			key.VerificationKey = privateKey.(ed25519.PrivateKey).Public()
+		} else if len(publicPEM) > 0 {
+			publicKey, err := jwt.ParseEdPublicKeyFromPEM(publicPEM)
+			if err != nil {
+				return Key{}, fmt.Errorf("jwt key %s: %w", id, err)
+			}
+			key.VerificationKey = publicKey
+		}
+	default:
+		return Key{}, fmt.Errorf("jwt key %s: unsupported algorithm %q", id, algorithm)
+	}
+
+	if key.VerificationKey == nil {
+		return Key{}, fmt.Errorf("jwt key %s: no private or public key given", id)
+	}
+
+	return key, nil
+}
+
+// JWK returns the public half of the key in JSON Web Key form. HMAC keys are
+// secret and have no public form, so ok is false for them.
+func (key Key) JWK() (jwk JWK, ok bool) {
+	jwk = JWK{
+		KeyId:     key.Id,
+		Use:       "sig",
+		Algorithm: key.Method.Alg(),
+	}
+
+	switch publicKey := key.VerificationKey.(type) {
+	case *rsa.PublicKey:
+		jwk.KeyType = "RSA"
+		jwk.Modulus = base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes())
+		jwk.Exponent = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.KeyType = "OKP"
+		jwk.Curve = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(publicKey)
+	default:
+		return JWK{}, false
+	}
+
+	return jwk, true
+}
@@ -0,0 +1,59 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	UserId   uuid.UUID
+	Username string
+	Roles    []string
+}
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns the caller stored by the auth middleware, or
+// ErrUnauthorized when the request was not authenticated.
+func PrincipalFromContext(ctx context.Context) (Principal, error) {
+	principal, ok := ctx.Value(principalKey{}).(Principal)
+	if !ok {
+		return Principal{}, ErrUnauthorized
+	}
+
+	return principal, nil
+}
+
+// AuthorizeOwner checks that the caller in ctx owns the resource owned by ownerId and
+// returns ErrForbidden otherwise. It guards edits: nobody changes what another user wrote.
+func AuthorizeOwner(ctx context.Context, ownerId uuid.UUID) error {
+	principal, err := PrincipalFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	if principal.UserId != ownerId {
+		return ErrForbidden
+	}
+
+	return nil
+}
+
+// AuthorizeOwnerOrModerator is AuthorizeOwner letting moderators through as well. It guards
+// deletes, so moderators can take down what breaks the rules.
+func AuthorizeOwnerOrModerator(ctx context.Context, ownerId uuid.UUID) error {
+	principal, err := PrincipalFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	if principal.UserId != ownerId && !principal.HasRole(RoleModerator) {
+		return ErrForbidden
+	}
+
+	return nil
+}
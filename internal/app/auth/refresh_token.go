@@ -0,0 +1,25 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// NewRefreshToken returns a random opaque refresh token and the hash to store in its place.
+func NewRefreshToken() (token string, hash string, err error) {
+	buf := make([]byte, 32)
+	_, err = rand.Read(buf)
+	if err != nil {
+		return "", "", err
+	}
+
+	token = base64.RawURLEncoding.EncodeToString(buf)
+	return token, HashRefreshToken(token), nil
+}
+
+func HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
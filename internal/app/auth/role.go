@@ -0,0 +1,38 @@
+package auth
+
+import "github.com/dihanto/gosnap/internal/app/exception"
+
+// Roles are ordered: a moderator can do everything a user can, and an admin everything a moderator can.
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+var roleRanks = map[string]int{
+	RoleUser:      1,
+	RoleModerator: 2,
+	RoleAdmin:     3,
+}
+
+// ErrForbidden is returned when an authenticated caller is not allowed to do something.
+var ErrForbidden = exception.Forbidden("forbidden", "Forbidden")
+
+// ErrAccountSuspended is returned when a suspended user tries to sign in.
+var ErrAccountSuspended = exception.Forbidden("account_suspended", "Account suspended")
+
+// HasRole reports whether the principal holds role or a role above it.
+func (principal Principal) HasRole(role string) bool {
+	required, ok := roleRanks[role]
+	if !ok {
+		return false
+	}
+
+	for _, held := range principal.Roles {
+		if roleRanks[held] >= required {
+			return true
+		}
+	}
+
+	return false
+}
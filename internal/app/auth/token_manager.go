@@ -0,0 +1,153 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/dihanto/gosnap/internal/app/exception"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// ErrUnauthorized is returned for every token that fails verification.
+var ErrUnauthorized = exception.Unauthorized("unauthorized", "Unauthorized")
+
+// Claims are the claims carried by gosnap access tokens.
+type Claims struct {
+	UserId   uuid.UUID `json:"id"`
+	Username string    `json:"username"`
+	Roles    []string  `json:"roles"`
+	jwt.RegisteredClaims
+}
+
+// Principal returns the caller the token was issued to.
+func (claims *Claims) Principal() Principal {
+	return Principal{
+		UserId:   claims.UserId,
+		Username: claims.Username,
+		Roles:    claims.Roles,
+	}
+}
+
+// TokenManager issues access tokens with its signing key and verifies them against
+// every configured key, so old keys can keep verifying tokens while a new one is rolled out.
+type TokenManager struct {
+	Issuer     string
+	Audience   string
+	TTL        time.Duration
+	SigningKey Key
+	keys       map[string]Key
+	methods    []string
+}
+
+func NewTokenManager(issuer string, audience string, ttl time.Duration, signingKeyId string, keys []Key) (*TokenManager, error) {
+	if ttl <= 0 {
+		return nil, errors.New("jwt ttl must be positive")
+	}
+
+	manager := &TokenManager{
+		Issuer:   issuer,
+		Audience: audience,
+		TTL:      ttl,
+		keys:     map[string]Key{},
+	}
+	for _, key := range keys {
+		if _, ok := manager.keys[key.Id]; ok {
+			return nil, fmt.Errorf("jwt key id %q is used by more than one key", key.Id)
+		}
+		manager.keys[key.Id] = key
+		manager.methods = append(manager.methods, key.Method.Alg())
+	}
+
+	signingKey, ok := manager.keys[signingKeyId]
+	if !ok {
+		return nil, fmt.Errorf("jwt signing key %q is not configured", signingKeyId)
+	}
+	if signingKey.SigningKey == nil {
+		return nil, fmt.Errorf("jwt signing key %q has no private key", signingKeyId)
+	}
+	manager.SigningKey = signingKey
+
+	return manager, nil
+}
+
+// Issue signs a new access token for the given user.
+func (manager *TokenManager) Issue(userId uuid.UUID, username string, roles []string) (string, error) {
+	now := time.Now()
+	claims := Claims{
+		UserId:   userId,
+		Username: username,
+		Roles:    roles,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    manager.Issuer,
+			Subject:   userId.String(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(manager.TTL)),
+		},
+	}
+	if manager.Audience != "" {
+		claims.Audience = jwt.ClaimStrings{manager.Audience}
+	}
+
+	token := jwt.NewWithClaims(manager.SigningKey.Method, claims)
+	if manager.SigningKey.Id != "" {
+		token.Header["kid"] = manager.SigningKey.Id
+	}
+
+	return token.SignedString(manager.SigningKey.SigningKey)
+}
+
+// Parse verifies tokenString and returns its claims. Any failure is wrapped in ErrUnauthorized.
+func (manager *TokenManager) Parse(tokenString string) (*Claims, error) {
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods(manager.methods),
+		jwt.WithExpirationRequired(),
+	}
+	if manager.Issuer != "" {
+		options = append(options, jwt.WithIssuer(manager.Issuer))
+	}
+	if manager.Audience != "" {
+		options = append(options, jwt.WithAudience(manager.Audience))
+	}
+
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, manager.keyfunc, options...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnauthorized, err)
+	}
+
+	return claims, nil
+}
+
+// keyfunc picks the verification key named by the token's kid header.
+func (manager *TokenManager) keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := manager.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method %s for key id %q", token.Method.Alg(), kid)
+	}
+
+	return key.VerificationKey, nil
+}
+
+// JWKS returns the public keys tokens may be verified with, for other services to fetch.
+func (manager *TokenManager) JWKS() JWKSet {
+	set := JWKSet{Keys: []JWK{}}
+	for _, key := range manager.keys {
+		jwk, ok := key.JWK()
+		if ok {
+			set.Keys = append(set.Keys, jwk)
+		}
+	}
+	sort.Slice(set.Keys, func(i, j int) bool {
+		return set.Keys[i].KeyId < set.Keys[j].KeyId
+	})
+
+	return set
+}
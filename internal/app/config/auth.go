@@ -0,0 +1,71 @@
+package config
+
+import (
+	"errors"
+	"os"
+
+	"github.com/dihanto/gosnap/internal/app/auth"
+	"github.com/spf13/viper"
+)
+
+type jwtKeyConfig struct {
+	Id             string `mapstructure:"id"`
+	Algorithm      string `mapstructure:"algorithm"`
+	Secret         string `mapstructure:"secret"`
+	PrivateKeyFile string `mapstructure:"privateKeyFile"`
+	PublicKeyFile  string `mapstructure:"publicKeyFile"`
+}
+
+func InitTokenManager() (*auth.TokenManager, error) {
+	issuer := viper.GetString("jwt.issuer")
+	audience := viper.GetString("jwt.audience")
+	ttl := viper.GetDuration("jwt.ttl")
+	signingKeyId := viper.GetString("jwt.signingKey")
+
+	var keyConfigs []jwtKeyConfig
+	err := viper.UnmarshalKey("jwt.keys", &keyConfigs)
+	if err != nil {
+		return nil, err
+	}
+	// older configs only carry a single jwt.secretKey
+	if len(keyConfigs) == 0 && viper.GetString("jwt.secretKey") != "" {
+		keyConfigs = append(keyConfigs, jwtKeyConfig{Id: signingKeyId, Secret: viper.GetString("jwt.secretKey")})
+	}
+	if len(keyConfigs) == 0 {
+		return nil, errors.New("no jwt keys configured")
+	}
+
+	keys := make([]auth.Key, 0, len(keyConfigs))
+	for _, keyConfig := range keyConfigs {
+		key, err := loadJWTKey(keyConfig)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return auth.NewTokenManager(issuer, audience, ttl, signingKeyId, keys)
+}
+
+func loadJWTKey(keyConfig jwtKeyConfig) (auth.Key, error) {
+	if keyConfig.Algorithm == "" || keyConfig.Algorithm == "HS256" {
+		return auth.NewHMACKey(keyConfig.Id, []byte(keyConfig.Secret))
+	}
+
+	var privatePEM, publicPEM []byte
+	var err error
+	if keyConfig.PrivateKeyFile != "" {
+		privatePEM, err = os.ReadFile(keyConfig.PrivateKeyFile)
+		if err != nil {
+			return auth.Key{}, err
+		}
+	}
+	if keyConfig.PublicKeyFile != "" {
+		publicPEM, err = os.ReadFile(keyConfig.PublicKeyFile)
+		if err != nil {
+			return auth.Key{}, err
+		}
+	}
+
+	return auth.NewPEMKey(keyConfig.Id, keyConfig.Algorithm, privatePEM, publicPEM)
+}
@@ -0,0 +1,30 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/dihanto/gosnap/internal/app/storage"
+	"github.com/spf13/viper"
+)
+
+func InitBlobStore() (storage.BlobStore, error) {
+	driver := viper.GetString("storage.driver")
+
+	switch driver {
+	case "", "local":
+		root := viper.GetString("storage.local.root")
+		baseURL := viper.GetString("storage.local.baseUrl")
+		return storage.NewLocalBlobStore(root, baseURL), nil
+	case "s3":
+		endpoint := viper.GetString("storage.s3.endpoint")
+		region := viper.GetString("storage.s3.region")
+		bucket := viper.GetString("storage.s3.bucket")
+		accessKey := viper.GetString("storage.s3.accessKey")
+		secretKey := viper.GetString("storage.s3.secretKey")
+		pathStyle := viper.GetBool("storage.s3.usePathStyle")
+		publicURL := viper.GetString("storage.s3.publicUrl")
+		return storage.NewS3BlobStore(endpoint, region, bucket, accessKey, secretKey, pathStyle, publicURL)
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", driver)
+	}
+}
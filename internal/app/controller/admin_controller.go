@@ -0,0 +1,11 @@
+package controller
+
+import "github.com/labstack/echo/v4"
+
+type AdminController interface {
+	DeletePhoto(ctx echo.Context) error
+	DeleteComment(ctx echo.Context) error
+	SuspendUser(ctx echo.Context) error
+	UnsuspendUser(ctx echo.Context) error
+	UpdateUserRole(ctx echo.Context) error
+}
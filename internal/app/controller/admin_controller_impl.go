@@ -0,0 +1,144 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/dihanto/gosnap/internal/app/auth"
+	"github.com/dihanto/gosnap/internal/app/middleware"
+	"github.com/dihanto/gosnap/internal/app/usecase"
+	"github.com/dihanto/gosnap/model/web/request"
+	"github.com/dihanto/gosnap/model/web/response"
+	"github.com/labstack/echo/v4"
+)
+
+type AdminControllerImpl struct {
+	PhotoUsecase   usecase.PhotoUsecase
+	CommentUsecase usecase.CommentUsecase
+	UserUsecase    usecase.UserUsecase
+	Route          *echo.Echo
+}
+
+func NewAdminController(photoUsecase usecase.PhotoUsecase, commentUsecase usecase.CommentUsecase, userUsecase usecase.UserUsecase, route *echo.Echo) AdminController {
+	controller := &AdminControllerImpl{
+		PhotoUsecase:   photoUsecase,
+		CommentUsecase: commentUsecase,
+		UserUsecase:    userUsecase,
+		Route:          route,
+	}
+
+	controller.route(route)
+	return controller
+}
+
+func (controller *AdminControllerImpl) route(echo *echo.Echo) {
+	moderationGroup := echo.Group("/admin", middleware.Auth, middleware.RequireRole(auth.RoleModerator))
+	moderationGroup.DELETE("/photos/:photoId", controller.DeletePhoto)
+	moderationGroup.DELETE("/comments/:commentId", controller.DeleteComment)
+
+	adminGroup := echo.Group("/admin/users", middleware.Auth, middleware.RequireRole(auth.RoleAdmin))
+	adminGroup.PUT("/:userId/suspension", controller.SuspendUser)
+	adminGroup.DELETE("/:userId/suspension", controller.UnsuspendUser)
+	adminGroup.PUT("/:userId/role", controller.UpdateUserRole)
+}
+
+func (controller *AdminControllerImpl) DeletePhoto(ctx echo.Context) error {
+	id, err := intParam(ctx, "photoId")
+	if err != nil {
+		return err
+	}
+
+	err = controller.PhotoUsecase.DeletePhoto(ctx.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+
+	webResponse := response.WebResponse{
+		Status:  http.StatusOK,
+		Message: "Photo has been successfully deleted",
+	}
+
+	return ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (controller *AdminControllerImpl) DeleteComment(ctx echo.Context) error {
+	id, err := intParam(ctx, "commentId")
+	if err != nil {
+		return err
+	}
+
+	err = controller.CommentUsecase.DeleteComment(ctx.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+
+	webResponse := response.WebResponse{
+		Status:  http.StatusOK,
+		Message: "Comment has been successfully deleted",
+	}
+
+	return ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (controller *AdminControllerImpl) SuspendUser(ctx echo.Context) error {
+	id, err := uuidParam(ctx, "userId")
+	if err != nil {
+		return err
+	}
+
+	err = controller.UserUsecase.SuspendUser(ctx.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+
+	webResponse := response.WebResponse{
+		Status:  http.StatusOK,
+		Message: "User has been suspended",
+	}
+
+	return ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (controller *AdminControllerImpl) UnsuspendUser(ctx echo.Context) error {
+	id, err := uuidParam(ctx, "userId")
+	if err != nil {
+		return err
+	}
+
+	err = controller.UserUsecase.UnsuspendUser(ctx.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+
+	webResponse := response.WebResponse{
+		Status:  http.StatusOK,
+		Message: "User suspension has been lifted",
+	}
+
+	return ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (controller *AdminControllerImpl) UpdateUserRole(ctx echo.Context) error {
+	request := request.UserRole{}
+	err := json.NewDecoder(ctx.Request().Body).Decode(&request)
+	if err != nil {
+		return err
+	}
+
+	request.Id, err = uuidParam(ctx, "userId")
+	if err != nil {
+		return err
+	}
+
+	err = controller.UserUsecase.UpdateUserRole(ctx.Request().Context(), request)
+	if err != nil {
+		return err
+	}
+
+	webResponse := response.WebResponse{
+		Status:  http.StatusOK,
+		Message: "User role has been updated",
+	}
+
+	return ctx.JSON(http.StatusOK, webResponse)
+}
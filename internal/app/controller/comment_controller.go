@@ -5,6 +5,10 @@ import "github.com/labstack/echo/v4"
 type CommentController interface {
 	PostComment(ctx echo.Context) error
 	GetComment(ctx echo.Context) error
+	GetPhotoComments(ctx echo.Context) error
+	GetReplies(ctx echo.Context) error
 	UpdateComment(ctx echo.Context) error
 	DeleteComment(ctx echo.Context) error
+	PinComment(ctx echo.Context) error
+	UnpinComment(ctx echo.Context) error
 }
@@ -3,10 +3,8 @@ package controller
 import (
 	"encoding/json"
 	"net/http"
-	"strconv"
-	"strings"
 
-	"github.com/dihanto/gosnap/internal/app/helper"
+	"github.com/dihanto/gosnap/internal/app/auth"
 	"github.com/dihanto/gosnap/internal/app/middleware"
 	"github.com/dihanto/gosnap/internal/app/usecase"
 	"github.com/dihanto/gosnap/model/web/request"
@@ -34,6 +32,10 @@ func (commentControllerImpl *CommentControllerImpl) route(echo *echo.Echo) {
 	commentsGroup.GET("", commentControllerImpl.GetComment)
 	commentsGroup.PUT("/:commentId", commentControllerImpl.UpdateComment)
 	commentsGroup.DELETE("/:commentId", commentControllerImpl.DeleteComment)
+	commentsGroup.GET("/:commentId/replies", commentControllerImpl.GetReplies)
+	commentsGroup.PUT("/:commentId/pin", commentControllerImpl.PinComment)
+	commentsGroup.DELETE("/:commentId/pin", commentControllerImpl.UnpinComment)
+	echo.GET("/photos/:photoId/comments", commentControllerImpl.GetPhotoComments, middleware.Auth)
 }
 
 func (controller *CommentControllerImpl) PostComment(ctx echo.Context) error {
@@ -43,12 +45,11 @@ func (controller *CommentControllerImpl) PostComment(ctx echo.Context) error {
 		return err
 	}
 
-	authHeader := ctx.Request().Header.Get("Authorization")
-	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-	request.UserId, err = helper.GetUserDataFromToken(tokenString)
+	principal, err := auth.PrincipalFromContext(ctx.Request().Context())
 	if err != nil {
 		return err
 	}
+	request.UserId = principal.UserId
 
 	commentResponse, err := controller.Usecase.PostComment(ctx.Request().Context(), request)
 	if err != nil {
@@ -65,15 +66,73 @@ func (controller *CommentControllerImpl) PostComment(ctx echo.Context) error {
 }
 
 func (controller *CommentControllerImpl) GetComment(ctx echo.Context) error {
-	commentResponse, err := controller.Usecase.GetComment(ctx.Request().Context())
+	page, err := bindPage(ctx)
+	if err != nil {
+		return err
+	}
+
+	commentResponse, nextCursor, err := controller.Usecase.GetComment(ctx.Request().Context(), page)
 	if err != nil {
 		return err
 	}
 
 	webResponse := response.WebResponse{
-		Status:  http.StatusOK,
-		Message: "Success get all comments",
-		Data:    commentResponse,
+		Status:     http.StatusOK,
+		Message:    "Success get all comments",
+		Data:       commentResponse,
+		NextCursor: nextCursor,
+	}
+
+	return ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (controller *CommentControllerImpl) GetPhotoComments(ctx echo.Context) error {
+	photoId, err := intParam(ctx, "photoId")
+	if err != nil {
+		return err
+	}
+
+	page, err := bindPage(ctx)
+	if err != nil {
+		return err
+	}
+
+	commentResponse, nextCursor, err := controller.Usecase.GetPhotoComments(ctx.Request().Context(), photoId, page)
+	if err != nil {
+		return err
+	}
+
+	webResponse := response.WebResponse{
+		Status:     http.StatusOK,
+		Message:    "Success get photo comments",
+		Data:       commentResponse,
+		NextCursor: nextCursor,
+	}
+
+	return ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (controller *CommentControllerImpl) GetReplies(ctx echo.Context) error {
+	commentId, err := intParam(ctx, "commentId")
+	if err != nil {
+		return err
+	}
+
+	page, err := bindPage(ctx)
+	if err != nil {
+		return err
+	}
+
+	commentResponse, nextCursor, err := controller.Usecase.GetReplies(ctx.Request().Context(), commentId, page)
+	if err != nil {
+		return err
+	}
+
+	webResponse := response.WebResponse{
+		Status:     http.StatusOK,
+		Message:    "Success get comment replies",
+		Data:       commentResponse,
+		NextCursor: nextCursor,
 	}
 
 	return ctx.JSON(http.StatusOK, webResponse)
@@ -86,17 +145,16 @@ func (controller *CommentControllerImpl) UpdateComment(ctx echo.Context) error {
 		return err
 	}
 
-	request.Id, err = strconv.Atoi(ctx.Param("commentId"))
+	request.Id, err = intParam(ctx, "commentId")
 	if err != nil {
 		return err
 	}
 
-	authHeader := ctx.Request().Header.Get("Authorization")
-	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-	request.UserId, err = helper.GetUserDataFromToken(tokenString)
+	principal, err := auth.PrincipalFromContext(ctx.Request().Context())
 	if err != nil {
 		return err
 	}
+	request.UserId = principal.UserId
 
 	commentResponse, err := controller.Usecase.UpdateComment(ctx.Request().Context(), request)
 	if err != nil {
@@ -113,7 +171,7 @@ func (controller *CommentControllerImpl) UpdateComment(ctx echo.Context) error {
 }
 
 func (controller *CommentControllerImpl) DeleteComment(ctx echo.Context) error {
-	id, err := strconv.Atoi(ctx.Param("commentId"))
+	id, err := intParam(ctx, "commentId")
 	if err != nil {
 		return err
 	}
@@ -130,3 +188,41 @@ func (controller *CommentControllerImpl) DeleteComment(ctx echo.Context) error {
 
 	return ctx.JSON(http.StatusOK, webResponse)
 }
+
+func (controller *CommentControllerImpl) PinComment(ctx echo.Context) error {
+	id, err := intParam(ctx, "commentId")
+	if err != nil {
+		return err
+	}
+
+	err = controller.Usecase.PinComment(ctx.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+
+	webResponse := response.WebResponse{
+		Status:  http.StatusOK,
+		Message: "The comment has been pinned",
+	}
+
+	return ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (controller *CommentControllerImpl) UnpinComment(ctx echo.Context) error {
+	id, err := intParam(ctx, "commentId")
+	if err != nil {
+		return err
+	}
+
+	err = controller.Usecase.UnpinComment(ctx.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+
+	webResponse := response.WebResponse{
+		Status:  http.StatusOK,
+		Message: "The comment has been unpinned",
+	}
+
+	return ctx.JSON(http.StatusOK, webResponse)
+}
@@ -2,9 +2,8 @@ package controller
 
 import (
 	"net/http"
-	"strings"
 
-	"github.com/dihanto/gosnap/internal/app/helper"
+	"github.com/dihanto/gosnap/internal/app/auth"
 	"github.com/dihanto/gosnap/internal/app/middleware"
 	"github.com/dihanto/gosnap/internal/app/usecase"
 	"github.com/dihanto/gosnap/model/web/request"
@@ -38,12 +37,11 @@ func (controller *FollowControllerImpl) route(echo *echo.Echo) {
 
 func (controller *FollowControllerImpl) FollowUser(ctx echo.Context) (err error) {
 	request := request.Follow{}
-	authHeader := ctx.Request().Header.Get("Authorization")
-	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-	request.FollowerUsername, err = helper.GetUsernameFromToken(tokenString)
+	principal, err := auth.PrincipalFromContext(ctx.Request().Context())
 	if err != nil {
 		return
 	}
+	request.FollowerId = principal.UserId
 
 	request.TargetUsername = ctx.Param("username")
 
@@ -68,12 +66,11 @@ func (controller *FollowControllerImpl) FollowUser(ctx echo.Context) (err error)
 
 func (controller *FollowControllerImpl) UnfollowUser(ctx echo.Context) (err error) {
 	request := request.Follow{}
-	authHeader := ctx.Request().Header.Get("Authorization")
-	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-	request.FollowerUsername, err = helper.GetUsernameFromToken(tokenString)
+	principal, err := auth.PrincipalFromContext(ctx.Request().Context())
 	if err != nil {
 		return
 	}
+	request.FollowerId = principal.UserId
 
 	request.TargetUsername = ctx.Param("username")
 
@@ -96,46 +93,52 @@ func (controller *FollowControllerImpl) UnfollowUser(ctx echo.Context) (err erro
 }
 
 func (controller *FollowControllerImpl) GetFollower(ctx echo.Context) (err error) {
-	request := request.Follow{}
-	authHeader := ctx.Request().Header.Get("Authorization")
-	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-	request.TargetUsername, err = helper.GetUsernameFromToken(tokenString)
+	principal, err := auth.PrincipalFromContext(ctx.Request().Context())
+	if err != nil {
+		return
+	}
+
+	page, err := bindPage(ctx)
 	if err != nil {
 		return
 	}
 
-	followers, err := controller.Usecase.GetFollower(ctx.Request().Context(), request)
+	followers, nextCursor, err := controller.Usecase.GetFollower(ctx.Request().Context(), principal.UserId, page)
 	if err != nil {
 		return
 	}
 
 	webResponse := response.WebResponse{
-		Status:  http.StatusOK,
-		Message: "Success get follower",
-		Data:    followers,
+		Status:     http.StatusOK,
+		Message:    "Success get follower",
+		Data:       followers,
+		NextCursor: nextCursor,
 	}
 
 	return ctx.JSON(http.StatusOK, webResponse)
 }
 
 func (controller *FollowControllerImpl) GetFollowing(ctx echo.Context) (err error) {
-	request := request.Follow{}
-	authHeader := ctx.Request().Header.Get("Authorization")
-	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-	request.TargetUsername, err = helper.GetUsernameFromToken(tokenString)
+	principal, err := auth.PrincipalFromContext(ctx.Request().Context())
+	if err != nil {
+		return
+	}
+
+	page, err := bindPage(ctx)
 	if err != nil {
 		return
 	}
 
-	follows, err := controller.Usecase.GetFollowing(ctx.Request().Context(), request)
+	follows, nextCursor, err := controller.Usecase.GetFollowing(ctx.Request().Context(), principal.UserId, page)
 	if err != nil {
 		return
 	}
 
 	webResponse := response.WebResponse{
-		Status:  http.StatusOK,
-		Message: "Get following success",
-		Data:    follows,
+		Status:     http.StatusOK,
+		Message:    "Get following success",
+		Data:       follows,
+		NextCursor: nextCursor,
 	}
 
 	return ctx.JSON(http.StatusOK, webResponse)
@@ -0,0 +1,33 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/dihanto/gosnap/internal/app/auth"
+	"github.com/labstack/echo/v4"
+)
+
+type JWKSControllerImpl struct {
+	Tokens *auth.TokenManager
+	Route  *echo.Echo
+}
+
+func NewJWKSController(tokens *auth.TokenManager, route *echo.Echo) JWKSController {
+	controller := &JWKSControllerImpl{
+		Tokens: tokens,
+		Route:  route,
+	}
+	controller.route(route)
+	return controller
+}
+
+func (controller *JWKSControllerImpl) route(echo *echo.Echo) {
+	echo.GET("/.well-known/jwks.json", controller.GetJWKS)
+}
+
+// GetJWKS serves the public verification keys as a plain JWK set, not wrapped in a
+// WebResponse, so standard JWT libraries can consume it directly.
+func (controller *JWKSControllerImpl) GetJWKS(ctx echo.Context) error {
+	ctx.Response().Header().Set(echo.HeaderCacheControl, "public, max-age=300")
+	return ctx.JSON(http.StatusOK, controller.Tokens.JWKS())
+}
@@ -6,4 +6,6 @@ type LikeController interface {
 	LikePhoto(ctx echo.Context) error
 	UnlikePhoto(ctx echo.Context) error
 	IsLikePhoto(ctx echo.Context) error
+	LikeComment(ctx echo.Context) error
+	UnlikeComment(ctx echo.Context) error
 }
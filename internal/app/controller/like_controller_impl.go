@@ -2,10 +2,9 @@ package controller
 
 import (
 	"net/http"
-	"strconv"
-	"strings"
 
-	"github.com/dihanto/gosnap/internal/app/helper"
+	"github.com/dihanto/gosnap/internal/app/auth"
+	"github.com/dihanto/gosnap/internal/app/middleware"
 	"github.com/dihanto/gosnap/internal/app/usecase"
 	"github.com/dihanto/gosnap/model/web/request"
 	"github.com/dihanto/gosnap/model/web/response"
@@ -29,24 +28,25 @@ func NewLikeController(usecase usecase.LikeUsecase, route *echo.Echo) LikeContro
 
 func (likeControllerImpl *LikeControllerImpl) route(echo *echo.Echo) {
 	photosGroup := echo.Group("/photos")
-	photosGroup.POST("/:photoId/likes", likeControllerImpl.LikePhoto)
-	photosGroup.GET("/:photoId/likes", likeControllerImpl.IsLikePhoto)
-	photosGroup.DELETE("/:photoId/unlikes", likeControllerImpl.UnlikePhoto)
+	photosGroup.POST("/:photoId/likes", likeControllerImpl.LikePhoto, middleware.Auth)
+	photosGroup.GET("/:photoId/likes", likeControllerImpl.IsLikePhoto, middleware.Auth)
+	photosGroup.DELETE("/:photoId/unlikes", likeControllerImpl.UnlikePhoto, middleware.Auth)
+	commentsGroup := echo.Group("/comments")
+	commentsGroup.POST("/:commentId/likes", likeControllerImpl.LikeComment, middleware.Auth)
+	commentsGroup.DELETE("/:commentId/unlikes", likeControllerImpl.UnlikeComment, middleware.Auth)
 }
 
 func (controller *LikeControllerImpl) LikePhoto(ctx echo.Context) error {
-	idString := ctx.Param("photoId")
-	photoId, err := strconv.Atoi(idString)
+	photoId, err := intParam(ctx, "photoId")
 	if err != nil {
 		return err
 	}
 
-	authHeader := ctx.Request().Header.Get("Authorization")
-	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-	userId, err := helper.GetUserDataFromToken(tokenString)
+	principal, err := auth.PrincipalFromContext(ctx.Request().Context())
 	if err != nil {
 		return err
 	}
+	userId := principal.UserId
 
 	likeRequest := request.Like{
 		PhotoId: photoId,
@@ -68,18 +68,16 @@ func (controller *LikeControllerImpl) LikePhoto(ctx echo.Context) error {
 }
 
 func (controller *LikeControllerImpl) UnlikePhoto(ctx echo.Context) error {
-	idString := ctx.Param("photoId")
-	photoId, err := strconv.Atoi(idString)
+	photoId, err := intParam(ctx, "photoId")
 	if err != nil {
 		return err
 	}
 
-	authHeader := ctx.Request().Header.Get("Authorization")
-	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-	userId, err := helper.GetUserDataFromToken(tokenString)
+	principal, err := auth.PrincipalFromContext(ctx.Request().Context())
 	if err != nil {
 		return err
 	}
+	userId := principal.UserId
 
 	likeRequest := request.Like{
 		PhotoId: photoId,
@@ -101,18 +99,16 @@ func (controller *LikeControllerImpl) UnlikePhoto(ctx echo.Context) error {
 }
 
 func (controller *LikeControllerImpl) IsLikePhoto(ctx echo.Context) error {
-	idString := ctx.Param("photoId")
-	photoId, err := strconv.Atoi(idString)
+	photoId, err := intParam(ctx, "photoId")
 	if err != nil {
 		return err
 	}
 
-	authHeader := ctx.Request().Header.Get("Authorization")
-	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-	userId, err := helper.GetUserDataFromToken(tokenString)
+	principal, err := auth.PrincipalFromContext(ctx.Request().Context())
 	if err != nil {
 		return err
 	}
+	userId := principal.UserId
 
 	likeRequest := request.Like{
 		PhotoId: photoId,
@@ -132,3 +128,63 @@ func (controller *LikeControllerImpl) IsLikePhoto(ctx echo.Context) error {
 
 	return ctx.JSON(http.StatusOK, webResponse)
 }
+
+func (controller *LikeControllerImpl) LikeComment(ctx echo.Context) error {
+	commentId, err := intParam(ctx, "commentId")
+	if err != nil {
+		return err
+	}
+
+	principal, err := auth.PrincipalFromContext(ctx.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	likeRequest := request.CommentLike{
+		CommentId: commentId,
+		UserId:    principal.UserId,
+	}
+
+	like, err := controller.Usecase.LikeComment(ctx.Request().Context(), likeRequest)
+	if err != nil {
+		return err
+	}
+
+	webResponse := response.WebResponse{
+		Status:  http.StatusOK,
+		Message: "Like comment success",
+		Data:    like,
+	}
+
+	return ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (controller *LikeControllerImpl) UnlikeComment(ctx echo.Context) error {
+	commentId, err := intParam(ctx, "commentId")
+	if err != nil {
+		return err
+	}
+
+	principal, err := auth.PrincipalFromContext(ctx.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	likeRequest := request.CommentLike{
+		CommentId: commentId,
+		UserId:    principal.UserId,
+	}
+
+	like, err := controller.Usecase.UnlikeComment(ctx.Request().Context(), likeRequest)
+	if err != nil {
+		return err
+	}
+
+	webResponse := response.WebResponse{
+		Status:  http.StatusOK,
+		Message: "Success unlike comment",
+		Data:    like,
+	}
+
+	return ctx.JSON(http.StatusOK, webResponse)
+}
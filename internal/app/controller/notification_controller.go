@@ -0,0 +1,10 @@
+package controller
+
+import "github.com/labstack/echo/v4"
+
+type NotificationController interface {
+	GetNotifications(ctx echo.Context) error
+	MarkRead(ctx echo.Context) error
+	GetPreferences(ctx echo.Context) error
+	UpdatePreferences(ctx echo.Context) error
+}
@@ -0,0 +1,143 @@
+package controller
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/dihanto/gosnap/internal/app/auth"
+	"github.com/dihanto/gosnap/internal/app/middleware"
+	"github.com/dihanto/gosnap/internal/app/usecase"
+	"github.com/dihanto/gosnap/model/web/request"
+	"github.com/dihanto/gosnap/model/web/response"
+	"github.com/labstack/echo/v4"
+)
+
+type NotificationControllerImpl struct {
+	Usecase usecase.NotificationUsecase
+	Route   *echo.Echo
+}
+
+func NewNotificationController(usecase usecase.NotificationUsecase, route *echo.Echo) NotificationController {
+	controller := &NotificationControllerImpl{
+		Usecase: usecase,
+		Route:   route,
+	}
+
+	controller.route(route)
+	return controller
+}
+
+func (controller *NotificationControllerImpl) route(echo *echo.Echo) {
+	notificationsGroup := echo.Group("/notifications", middleware.Auth)
+	notificationsGroup.GET("", controller.GetNotifications)
+	notificationsGroup.POST("/read", controller.MarkRead)
+	notificationsGroup.GET("/preferences", controller.GetPreferences)
+	notificationsGroup.PUT("/preferences", controller.UpdatePreferences)
+}
+
+func (controller *NotificationControllerImpl) GetNotifications(ctx echo.Context) error {
+	principal, err := auth.PrincipalFromContext(ctx.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	page, err := bindPage(ctx)
+	if err != nil {
+		return err
+	}
+
+	request := request.Notifications{
+		UserId: principal.UserId,
+		Page:   page,
+	}
+
+	notifications, nextCursor, err := controller.Usecase.GetNotifications(ctx.Request().Context(), request)
+	if err != nil {
+		return err
+	}
+
+	webResponse := response.WebResponse{
+		Status:     http.StatusOK,
+		Message:    "Success get notifications",
+		Data:       notifications,
+		NextCursor: nextCursor,
+	}
+
+	return ctx.JSON(http.StatusOK, webResponse)
+}
+
+// MarkRead marks the notifications listed in the body as read, or all of them when the
+// body is empty.
+func (controller *NotificationControllerImpl) MarkRead(ctx echo.Context) error {
+	request := request.NotificationRead{}
+	err := json.NewDecoder(ctx.Request().Body).Decode(&request)
+	if err != nil && err != io.EOF {
+		return err
+	}
+
+	principal, err := auth.PrincipalFromContext(ctx.Request().Context())
+	if err != nil {
+		return err
+	}
+	request.UserId = principal.UserId
+
+	err = controller.Usecase.MarkRead(ctx.Request().Context(), request)
+	if err != nil {
+		return err
+	}
+
+	webResponse := response.WebResponse{
+		Status:  http.StatusOK,
+		Message: "Notifications have been marked as read",
+	}
+
+	return ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (controller *NotificationControllerImpl) GetPreferences(ctx echo.Context) error {
+	principal, err := auth.PrincipalFromContext(ctx.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	preferences, err := controller.Usecase.GetPreferences(ctx.Request().Context(), principal.UserId)
+	if err != nil {
+		return err
+	}
+
+	webResponse := response.WebResponse{
+		Status:  http.StatusOK,
+		Message: "Success get notification preferences",
+		Data:    preferences,
+	}
+
+	return ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (controller *NotificationControllerImpl) UpdatePreferences(ctx echo.Context) error {
+	request := request.NotificationPreferences{}
+	err := json.NewDecoder(ctx.Request().Body).Decode(&request)
+	if err != nil {
+		return err
+	}
+
+	principal, err := auth.PrincipalFromContext(ctx.Request().Context())
+	if err != nil {
+		return err
+	}
+	request.UserId = principal.UserId
+
+	preferences, err := controller.Usecase.UpdatePreferences(ctx.Request().Context(), request)
+	if err != nil {
+		return err
+	}
+
+	webResponse := response.WebResponse{
+		Status:  http.StatusOK,
+		Message: "Notification preferences have been updated",
+		Data:    preferences,
+	}
+
+	return ctx.JSON(http.StatusOK, webResponse)
+}
@@ -0,0 +1,17 @@
+package controller
+
+import (
+	"github.com/dihanto/gosnap/model/web/request"
+	"github.com/labstack/echo/v4"
+)
+
+// bindPage reads the ?cursor=...&limit=... query parameters shared by every list endpoint.
+func bindPage(ctx echo.Context) (request.Page, error) {
+	page := request.Page{}
+	err := echo.QueryParamsBinder(ctx).
+		String("cursor", &page.Cursor).
+		Int("limit", &page.Limit).
+		BindError()
+
+	return page, err
+}
@@ -0,0 +1,29 @@
+package controller
+
+import (
+	"strconv"
+
+	"github.com/dihanto/gosnap/internal/app/exception"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// intParam reads the path parameter name as an id, reporting one that isn't a number as a validation error.
+func intParam(ctx echo.Context, name string) (int, error) {
+	id, err := strconv.Atoi(ctx.Param(name))
+	if err != nil {
+		return 0, exception.Validation("invalid_path_param", name+" must be a number")
+	}
+
+	return id, nil
+}
+
+// uuidParam reads the path parameter name as a uuid, reporting one that isn't a uuid as a validation error.
+func uuidParam(ctx echo.Context, name string) (uuid.UUID, error) {
+	id, err := uuid.Parse(ctx.Param(name))
+	if err != nil {
+		return uuid.Nil, exception.Validation("invalid_path_param", name+" must be a uuid")
+	}
+
+	return id, nil
+}
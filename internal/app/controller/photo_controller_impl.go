@@ -2,10 +2,12 @@ package controller
 
 import (
 	"encoding/json"
+	"io"
 	"net/http"
-	"strconv"
+	"os"
 	"strings"
 
+	"github.com/dihanto/gosnap/internal/app/auth"
 	"github.com/dihanto/gosnap/internal/app/helper"
 	"github.com/dihanto/gosnap/internal/app/middleware"
 	"github.com/dihanto/gosnap/internal/app/usecase"
@@ -14,15 +16,20 @@ import (
 	"github.com/labstack/echo/v4"
 )
 
+// maxFormValueBytes bounds the text fields of a multipart photo upload.
+const maxFormValueBytes = 4096
+
 type PhotoControllerImpl struct {
-	Usecase usecase.PhotoUsecase
-	Route   *echo.Echo
+	Usecase        usecase.PhotoUsecase
+	Route          *echo.Echo
+	MaxUploadBytes int64
 }
 
-func NewPhotoController(usecase usecase.PhotoUsecase, route *echo.Echo) PhotoController {
+func NewPhotoController(usecase usecase.PhotoUsecase, route *echo.Echo, maxUploadBytes int64) PhotoController {
 	controller := &PhotoControllerImpl{
-		Usecase: usecase,
-		Route:   route,
+		Usecase:        usecase,
+		Route:          route,
+		MaxUploadBytes: maxUploadBytes,
 	}
 
 	controller.route(route)
@@ -36,22 +43,29 @@ func (photoControllerImpl *PhotoControllerImpl) route(echo *echo.Echo) {
 	photosGroup.PUT("/:photoId", photoControllerImpl.UpdatePhoto)
 	photosGroup.DELETE("/:photoId", photoControllerImpl.DeletePhoto)
 	photosGroup.GET("/:photoId", photoControllerImpl.GetPhotoById)
+	echo.GET("/feed", photoControllerImpl.GetFeed, middleware.Auth)
+	echo.GET("/tags/:tag/photos", photoControllerImpl.GetTagPhotos, middleware.Auth)
 }
 
 func (controller *PhotoControllerImpl) PostPhoto(ctx echo.Context) error {
+	if strings.HasPrefix(ctx.Request().Header.Get(echo.HeaderContentType), echo.MIMEMultipartForm) {
+		return controller.postPhotoMultipart(ctx)
+	}
+
 	request := request.Photo{}
 
-	err := json.NewDecoder(ctx.Request().Body).Decode(&request)
+	// base64 inflates the image by a third, leave room for that and the other fields
+	body := http.MaxBytesReader(ctx.Response(), ctx.Request().Body, controller.MaxUploadBytes*4/3+maxFormValueBytes)
+	err := json.NewDecoder(body).Decode(&request)
 	if err != nil {
 		return err
 	}
 
-	authHeader := ctx.Request().Header.Get("Authorization")
-	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-	request.UserId, err = helper.GetUserDataFromToken(tokenString)
+	principal, err := auth.PrincipalFromContext(ctx.Request().Context())
 	if err != nil {
 		return err
 	}
+	request.UserId = principal.UserId
 
 	photoResponse, err := controller.Usecase.PostPhoto(ctx.Request().Context(), request)
 	if err != nil {
@@ -67,24 +81,148 @@ func (controller *PhotoControllerImpl) PostPhoto(ctx echo.Context) error {
 	return ctx.JSON(http.StatusCreated, webResponse)
 }
 
-func (controller *PhotoControllerImpl) GetPhoto(ctx echo.Context) error {
+// postPhotoMultipart handles a multipart/form-data upload with "title",
+// "caption" and "photo" parts. The file part is streamed to disk while it is
+// read instead of being buffered in memory.
+func (controller *PhotoControllerImpl) postPhotoMultipart(ctx echo.Context) error {
+	request := request.PhotoUpload{}
 
-	page := ctx.QueryParam("page")
-	if page == "" {
-		page = "0"
+	principal, err := auth.PrincipalFromContext(ctx.Request().Context())
+	if err != nil {
+		return err
 	}
+	request.UserId = principal.UserId
 
-	photoResponse, err := controller.Usecase.GetPhoto(ctx.Request().Context(), page)
+	ctx.Request().Body = http.MaxBytesReader(ctx.Response(), ctx.Request().Body, controller.MaxUploadBytes+maxFormValueBytes*4)
+	reader, err := ctx.Request().MultipartReader()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		switch part.FormName() {
+		case "title", "caption":
+			value, err := io.ReadAll(io.LimitReader(part, maxFormValueBytes))
+			if err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+			}
+			if part.FormName() == "title" {
+				request.Title = string(value)
+			} else {
+				request.Caption = string(value)
+			}
+		case "photo":
+			if request.Photo != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, "only one photo can be uploaded at a time")
+			}
+			file, size, err := helper.SpoolUpload(part, controller.MaxUploadBytes)
+			if err != nil {
+				return err
+			}
+			defer os.Remove(file.Name())
+			defer file.Close()
+
+			request.Photo = file
+			request.Size = size
+		}
+		part.Close()
+	}
+
+	photoResponse, err := controller.Usecase.PostPhotoUpload(ctx.Request().Context(), request)
 	if err != nil {
 		return err
 	}
 
 	webResponse := response.WebResponse{
-		Status:  http.StatusOK,
-		Message: "Success get all photos",
+		Status:  http.StatusCreated,
+		Message: "Photo have been successfully posted",
 		Data:    photoResponse,
 	}
 
+	return ctx.JSON(http.StatusCreated, webResponse)
+}
+
+func (controller *PhotoControllerImpl) GetPhoto(ctx echo.Context) error {
+	page, err := bindPage(ctx)
+	if err != nil {
+		return err
+	}
+
+	photoResponse, nextCursor, err := controller.Usecase.GetPhoto(ctx.Request().Context(), page)
+	if err != nil {
+		return err
+	}
+
+	webResponse := response.WebResponse{
+		Status:     http.StatusOK,
+		Message:    "Success get all photos",
+		Data:       photoResponse,
+		NextCursor: nextCursor,
+	}
+
+	return ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (controller *PhotoControllerImpl) GetFeed(ctx echo.Context) error {
+	request := request.Feed{}
+
+	principal, err := auth.PrincipalFromContext(ctx.Request().Context())
+	if err != nil {
+		return err
+	}
+	request.UserId = principal.UserId
+
+	request.Page, err = bindPage(ctx)
+	if err != nil {
+		return err
+	}
+
+	photoResponse, nextCursor, err := controller.Usecase.GetFeed(ctx.Request().Context(), request)
+	if err != nil {
+		return err
+	}
+
+	webResponse := response.WebResponse{
+		Status:     http.StatusOK,
+		Message:    "Success get feed",
+		Data:       photoResponse,
+		NextCursor: nextCursor,
+	}
+
+	return ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (controller *PhotoControllerImpl) GetTagPhotos(ctx echo.Context) error {
+	request := request.TagPhotos{
+		Tag: ctx.Param("tag"),
+	}
+
+	var err error
+	request.Page, err = bindPage(ctx)
+	if err != nil {
+		return err
+	}
+
+	photoResponse, nextCursor, err := controller.Usecase.GetTagPhotos(ctx.Request().Context(), request)
+	if err != nil {
+		return err
+	}
+
+	webResponse := response.WebResponse{
+		Status:     http.StatusOK,
+		Message:    "Success get tag photos",
+		Data:       photoResponse,
+		NextCursor: nextCursor,
+	}
+
 	return ctx.JSON(http.StatusOK, webResponse)
 }
 
@@ -94,14 +232,13 @@ func (controller *PhotoControllerImpl) UpdatePhoto(ctx echo.Context) error {
 	if err != nil {
 		return err
 	}
-	authHeader := ctx.Request().Header.Get("Authorization")
-	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-	request.UserId, err = helper.GetUserDataFromToken(tokenString)
+	principal, err := auth.PrincipalFromContext(ctx.Request().Context())
 	if err != nil {
 		return err
 	}
+	request.UserId = principal.UserId
 
-	request.Id, err = strconv.Atoi(ctx.Param("photoId"))
+	request.Id, err = intParam(ctx, "photoId")
 	if err != nil {
 		return err
 	}
@@ -121,7 +258,7 @@ func (controller *PhotoControllerImpl) UpdatePhoto(ctx echo.Context) error {
 }
 
 func (controller *PhotoControllerImpl) DeletePhoto(ctx echo.Context) error {
-	id, err := strconv.Atoi(ctx.Param("photoId"))
+	id, err := intParam(ctx, "photoId")
 	if err != nil {
 		return err
 	}
@@ -140,20 +277,34 @@ func (controller *PhotoControllerImpl) DeletePhoto(ctx echo.Context) error {
 }
 
 func (controller *PhotoControllerImpl) GetPhotoById(ctx echo.Context) error {
-	id, err := strconv.Atoi(ctx.Param("photoId"))
+	request := request.PhotoDetail{}
+	id, err := intParam(ctx, "photoId")
 	if err != nil {
 		return err
 	}
+	request.Id = id
 
-	photo, err := controller.Usecase.GetPhotoById(ctx.Request().Context(), id)
+	principal, err := auth.PrincipalFromContext(ctx.Request().Context())
+	if err != nil {
+		return err
+	}
+	request.UserId = principal.UserId
+
+	request.Page, err = bindPage(ctx)
+	if err != nil {
+		return err
+	}
+
+	photo, nextCursor, err := controller.Usecase.GetPhotoById(ctx.Request().Context(), request)
 	if err != nil {
 		return err
 	}
 
 	webResponse := response.WebResponse{
-		Status:  http.StatusOK,
-		Message: "Get photo success",
-		Data:    photo,
+		Status:     http.StatusOK,
+		Message:    "Get photo success",
+		Data:       photo,
+		NextCursor: nextCursor,
 	}
 
 	return ctx.JSON(http.StatusOK, webResponse)
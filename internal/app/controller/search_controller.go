@@ -0,0 +1,7 @@
+package controller
+
+import "github.com/labstack/echo/v4"
+
+type SearchController interface {
+	Search(ctx echo.Context) error
+}
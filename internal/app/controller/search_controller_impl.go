@@ -0,0 +1,71 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/dihanto/gosnap/internal/app/middleware"
+	"github.com/dihanto/gosnap/internal/app/usecase"
+	"github.com/dihanto/gosnap/model/web/request"
+	"github.com/dihanto/gosnap/model/web/response"
+	"github.com/labstack/echo/v4"
+)
+
+type SearchControllerImpl struct {
+	PhotoUsecase usecase.PhotoUsecase
+	UserUsecase  usecase.UserUsecase
+	Route        *echo.Echo
+}
+
+func NewSearchController(photoUsecase usecase.PhotoUsecase, userUsecase usecase.UserUsecase, route *echo.Echo) SearchController {
+	controller := &SearchControllerImpl{
+		PhotoUsecase: photoUsecase,
+		UserUsecase:  userUsecase,
+		Route:        route,
+	}
+
+	controller.route(route)
+	return controller
+}
+
+func (controller *SearchControllerImpl) route(echo *echo.Echo) {
+	echo.GET("/search", controller.Search, middleware.Auth)
+}
+
+func (controller *SearchControllerImpl) Search(ctx echo.Context) error {
+	request := request.Search{}
+	err := echo.QueryParamsBinder(ctx).
+		String("q", &request.Query).
+		String("type", &request.Type).
+		BindError()
+	if err != nil {
+		return err
+	}
+	request.Page, err = bindPage(ctx)
+	if err != nil {
+		return err
+	}
+
+	var results interface{}
+	var nextCursor string
+	switch request.Type {
+	case "photos":
+		results, nextCursor, err = controller.PhotoUsecase.SearchPhotos(ctx.Request().Context(), request)
+	case "tags":
+		results, nextCursor, err = controller.PhotoUsecase.SearchTags(ctx.Request().Context(), request)
+	default:
+		// users, or a type the usecase rejects
+		results, nextCursor, err = controller.UserUsecase.SearchUsers(ctx.Request().Context(), request)
+	}
+	if err != nil {
+		return err
+	}
+
+	webResponse := response.WebResponse{
+		Status:     http.StatusOK,
+		Message:    "Search success",
+		Data:       results,
+		NextCursor: nextCursor,
+	}
+
+	return ctx.JSON(http.StatusOK, webResponse)
+}
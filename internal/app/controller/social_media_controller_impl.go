@@ -3,10 +3,8 @@ package controller
 import (
 	"encoding/json"
 	"net/http"
-	"strconv"
-	"strings"
 
-	"github.com/dihanto/gosnap/internal/app/helper"
+	"github.com/dihanto/gosnap/internal/app/auth"
 	"github.com/dihanto/gosnap/internal/app/middleware"
 	"github.com/dihanto/gosnap/internal/app/usecase"
 	"github.com/dihanto/gosnap/model/web/request"
@@ -43,12 +41,11 @@ func (controller *SocialMediaControllerImpl) PostSocialMedia(ctx echo.Context) e
 	if err != nil {
 		return err
 	}
-	authHeader := ctx.Request().Header.Get("Authorization")
-	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-	request.UserId, err = helper.GetUserDataFromToken(tokenString)
+	principal, err := auth.PrincipalFromContext(ctx.Request().Context())
 	if err != nil {
 		return err
 	}
+	request.UserId = principal.UserId
 
 	socialMediaResponse, err := controller.Usecase.PostSocialMedia(ctx.Request().Context(), request)
 	if err != nil {
@@ -65,15 +62,21 @@ func (controller *SocialMediaControllerImpl) PostSocialMedia(ctx echo.Context) e
 }
 
 func (controller *SocialMediaControllerImpl) GetSocialMedia(ctx echo.Context) error {
-	socialMediaResponse, err := controller.Usecase.GetSocialMedia(ctx.Request().Context())
+	page, err := bindPage(ctx)
+	if err != nil {
+		return err
+	}
+
+	socialMediaResponse, nextCursor, err := controller.Usecase.GetSocialMedia(ctx.Request().Context(), page)
 	if err != nil {
 		return err
 	}
 
 	webResponse := response.WebResponse{
-		Status:  http.StatusOK,
-		Message: "Success get all social media",
-		Data:    socialMediaResponse,
+		Status:     http.StatusOK,
+		Message:    "Success get all social media",
+		Data:       socialMediaResponse,
+		NextCursor: nextCursor,
 	}
 
 	return ctx.JSON(http.StatusOK, webResponse)
@@ -87,17 +90,16 @@ func (controller *SocialMediaControllerImpl) UpdateSocialMedia(ctx echo.Context)
 		return err
 	}
 
-	request.Id, err = strconv.Atoi(ctx.Param("socialMediaId"))
+	request.Id, err = intParam(ctx, "socialMediaId")
 	if err != nil {
 		return err
 	}
 
-	authHeader := ctx.Request().Header.Get("Authorization")
-	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-	request.UserId, err = helper.GetUserDataFromToken(tokenString)
+	principal, err := auth.PrincipalFromContext(ctx.Request().Context())
 	if err != nil {
 		return err
 	}
+	request.UserId = principal.UserId
 
 	socialMediaResponse, err := controller.Usecase.UpdateSocialMedia(ctx.Request().Context(), request)
 	if err != nil {
@@ -114,7 +116,7 @@ func (controller *SocialMediaControllerImpl) UpdateSocialMedia(ctx echo.Context)
 }
 
 func (controller *SocialMediaControllerImpl) DeleteSocialMedia(ctx echo.Context) error {
-	id, err := strconv.Atoi(ctx.Param("socialMediaId"))
+	id, err := intParam(ctx, "socialMediaId")
 	if err != nil {
 		return err
 	}
@@ -3,12 +3,9 @@ package controller
 import (
 	"encoding/json"
 	"net/http"
-	"strings"
-	"time"
+	"net/url"
 
-	"github.com/dgrijalva/jwt-go"
-
-	"github.com/dihanto/gosnap/internal/app/helper"
+	"github.com/dihanto/gosnap/internal/app/auth"
 	"github.com/dihanto/gosnap/internal/app/middleware"
 	"github.com/dihanto/gosnap/internal/app/usecase"
 	"github.com/dihanto/gosnap/model/web/request"
@@ -17,14 +14,16 @@ import (
 )
 
 type UserControllerImpl struct {
-	Usecase usecase.UserUsecase
-	Route   *echo.Echo
+	Usecase  usecase.UserUsecase
+	Sessions usecase.SessionUsecase
+	Route    *echo.Echo
 }
 
-func NewUserController(usecase usecase.UserUsecase, route *echo.Echo) UserController {
+func NewUserController(usecase usecase.UserUsecase, sessions usecase.SessionUsecase, route *echo.Echo) UserController {
 	controller := &UserControllerImpl{
-		Usecase: usecase,
-		Route:   route,
+		Usecase:  usecase,
+		Sessions: sessions,
+		Route:    route,
 	}
 	controller.route(route)
 	return controller
@@ -33,11 +32,15 @@ func (controller *UserControllerImpl) route(echo *echo.Echo) {
 	usersGroup := echo.Group("/users")
 	usersGroup.POST("/register", controller.UserRegister)
 	usersGroup.POST("/login", controller.UserLogin)
+	usersGroup.POST("/token/refresh", controller.RefreshToken)
+	usersGroup.POST("/logout", controller.UserLogout)
 	usersGroup.Use(middleware.Auth)
 	usersGroup.PUT("", controller.UserUpdate)
 	usersGroup.DELETE("", controller.UserDelete)
 	usersGroup.GET("", controller.FindUser)
 	usersGroup.GET("/all", controller.FindAllUser)
+	usersGroup.GET("/:username", controller.FindUserByUsername)
+	usersGroup.GET("/:username/mentions", controller.GetMentions)
 }
 
 func (controller *UserControllerImpl) UserRegister(ctx echo.Context) error {
@@ -70,23 +73,16 @@ func (controller *UserControllerImpl) UserLogin(ctx echo.Context) error {
 	username := request.Username
 	password := request.Password
 
-	res, id, err := controller.Usecase.UserLogin(ctx.Request().Context(), username, password)
+	res, user, err := controller.Usecase.UserLogin(ctx.Request().Context(), username, password)
 	if err != nil {
 		return err
 	}
 
 	if !res {
-		return echo.NewHTTPError(http.StatusUnauthorized, err.Error())
+		return auth.ErrUnauthorized
 	}
 
-	token := jwt.New(jwt.SigningMethodHS256)
-	claims := token.Claims.(jwt.MapClaims)
-	claims["username"] = username
-	claims["id"] = id
-	claims["level"] = "user"
-	claims["exp"] = time.Now().Add(time.Hour * 24).Unix()
-
-	t, err := token.SignedString([]byte("snapsecret"))
+	session, err := controller.Sessions.CreateSession(ctx.Request().Context(), user)
 	if err != nil {
 		return err
 	}
@@ -94,7 +90,48 @@ func (controller *UserControllerImpl) UserLogin(ctx echo.Context) error {
 	webResponse := response.WebResponse{
 		Status:  http.StatusOK,
 		Message: "Login Success",
-		Data:    t,
+		Data:    session,
+	}
+
+	return ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (controller *UserControllerImpl) RefreshToken(ctx echo.Context) error {
+	request := request.RefreshToken{}
+	err := json.NewDecoder(ctx.Request().Body).Decode(&request)
+	if err != nil {
+		return err
+	}
+
+	session, err := controller.Sessions.RefreshSession(ctx.Request().Context(), request)
+	if err != nil {
+		return err
+	}
+
+	webResponse := response.WebResponse{
+		Status:  http.StatusOK,
+		Message: "Token refreshed",
+		Data:    session,
+	}
+
+	return ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (controller *UserControllerImpl) UserLogout(ctx echo.Context) error {
+	request := request.RefreshToken{}
+	err := json.NewDecoder(ctx.Request().Body).Decode(&request)
+	if err != nil {
+		return err
+	}
+
+	err = controller.Sessions.RevokeSession(ctx.Request().Context(), request)
+	if err != nil {
+		return err
+	}
+
+	webResponse := response.WebResponse{
+		Status:  http.StatusOK,
+		Message: "Logout success",
 	}
 
 	return ctx.JSON(http.StatusOK, webResponse)
@@ -107,12 +144,11 @@ func (controller *UserControllerImpl) UserUpdate(ctx echo.Context) error {
 	if err != nil {
 		return err
 	}
-	authHeader := ctx.Request().Header.Get("Authorization")
-	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-	request.Id, err = helper.GetUserDataFromToken(tokenString)
+	principal, err := auth.PrincipalFromContext(ctx.Request().Context())
 	if err != nil {
 		return err
 	}
+	request.Id = principal.UserId
 
 	userResponse, err := controller.Usecase.UserUpdate(ctx.Request().Context(), request)
 	if err != nil {
@@ -129,12 +165,11 @@ func (controller *UserControllerImpl) UserUpdate(ctx echo.Context) error {
 }
 
 func (controller *UserControllerImpl) UserDelete(ctx echo.Context) error {
-	authHeader := ctx.Request().Header.Get("Authorization")
-	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-	id, err := helper.GetUserDataFromToken(tokenString)
+	principal, err := auth.PrincipalFromContext(ctx.Request().Context())
 	if err != nil {
 		return err
 	}
+	id := principal.UserId
 
 	err = controller.Usecase.UserDelete(ctx.Request().Context(), id)
 	if err != nil {
@@ -150,12 +185,11 @@ func (controller *UserControllerImpl) UserDelete(ctx echo.Context) error {
 }
 
 func (controller *UserControllerImpl) FindUser(ctx echo.Context) error {
-	authHeader := ctx.Request().Header.Get("Authorization")
-	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-	id, err := helper.GetUserDataFromToken(tokenString)
+	principal, err := auth.PrincipalFromContext(ctx.Request().Context())
 	if err != nil {
 		return err
 	}
+	id := principal.UserId
 
 	user, err := controller.Usecase.FindUser(ctx.Request().Context(), id)
 	if err != nil {
@@ -179,22 +213,71 @@ func (controller *UserControllerImpl) FindUser(ctx echo.Context) error {
 
 func (controller *UserControllerImpl) FindAllUser(ctx echo.Context) error {
 
-	authHeader := ctx.Request().Header.Get("Authorization")
-	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-	username, err := helper.GetUsernameFromToken(tokenString)
+	principal, err := auth.PrincipalFromContext(ctx.Request().Context())
+	if err != nil {
+		return err
+	}
+	page, err := bindPage(ctx)
 	if err != nil {
 		return err
 	}
 
-	users, err := controller.Usecase.FindAllUser(ctx.Request().Context(), username)
+	users, nextCursor, err := controller.Usecase.FindAllUser(ctx.Request().Context(), principal.UserId, page)
 	if err != nil {
 		return err
 	}
 
+	webResponse := response.WebResponse{
+		Status:     http.StatusOK,
+		Message:    "Find all users success",
+		Data:       users,
+		NextCursor: nextCursor,
+	}
+
+	return ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (controller *UserControllerImpl) FindUserByUsername(ctx echo.Context) error {
+	username := ctx.Param("username")
+
+	profile, err := controller.Usecase.FindUserByUsername(ctx.Request().Context(), username)
+	if err != nil {
+		return err
+	}
+	if profile.Username != username {
+		return ctx.Redirect(http.StatusMovedPermanently, "/users/"+url.PathEscape(profile.Username))
+	}
+
 	webResponse := response.WebResponse{
 		Status:  http.StatusOK,
-		Message: "Find all users success",
-		Data:    users,
+		Message: "Find user success",
+		Data:    profile,
+	}
+
+	return ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (controller *UserControllerImpl) GetMentions(ctx echo.Context) error {
+	request := request.Mentions{
+		Username: ctx.Param("username"),
+	}
+
+	var err error
+	request.Page, err = bindPage(ctx)
+	if err != nil {
+		return err
+	}
+
+	mentions, nextCursor, err := controller.Usecase.GetMentions(ctx.Request().Context(), request)
+	if err != nil {
+		return err
+	}
+
+	webResponse := response.WebResponse{
+		Status:     http.StatusOK,
+		Message:    "Success get mentions",
+		Data:       mentions,
+		NextCursor: nextCursor,
 	}
 
 	return ctx.JSON(http.StatusOK, webResponse)
@@ -0,0 +1,92 @@
+package exception
+
+// Kind classifies an Error. ErrorHandler picks the HTTP status from it, and it is
+// the fallback machine-readable code when an Error doesn't carry its own.
+type Kind string
+
+const (
+	KindNotFound         Kind = "not_found"
+	KindConflict         Kind = "conflict"
+	KindForbidden        Kind = "forbidden"
+	KindUnauthorized     Kind = "unauthorized"
+	KindValidation       Kind = "validation_failed"
+	KindRateLimited      Kind = "rate_limited"
+	KindTooLarge         Kind = "too_large"
+	KindUnsupportedMedia Kind = "unsupported_media_type"
+)
+
+// Sentinels for matching any error of a kind, e.g. errors.Is(err, exception.ErrNotFound).
+var (
+	ErrNotFound         = &Error{Kind: KindNotFound}
+	ErrConflict         = &Error{Kind: KindConflict}
+	ErrForbidden        = &Error{Kind: KindForbidden}
+	ErrUnauthorized     = &Error{Kind: KindUnauthorized}
+	ErrValidation       = &Error{Kind: KindValidation}
+	ErrRateLimited      = &Error{Kind: KindRateLimited}
+	ErrTooLarge         = &Error{Kind: KindTooLarge}
+	ErrUnsupportedMedia = &Error{Kind: KindUnsupportedMedia}
+)
+
+// Error is an error that is safe to show to clients. Code is a stable identifier
+// such as "photo_not_found" and Message a human readable description of it.
+type Error struct {
+	Kind    Kind
+	Code    string
+	Message string
+}
+
+func NotFound(code string, message string) *Error {
+	return &Error{Kind: KindNotFound, Code: code, Message: message}
+}
+
+func Conflict(code string, message string) *Error {
+	return &Error{Kind: KindConflict, Code: code, Message: message}
+}
+
+func Forbidden(code string, message string) *Error {
+	return &Error{Kind: KindForbidden, Code: code, Message: message}
+}
+
+func Unauthorized(code string, message string) *Error {
+	return &Error{Kind: KindUnauthorized, Code: code, Message: message}
+}
+
+func Validation(code string, message string) *Error {
+	return &Error{Kind: KindValidation, Code: code, Message: message}
+}
+
+func RateLimited(code string, message string) *Error {
+	return &Error{Kind: KindRateLimited, Code: code, Message: message}
+}
+
+func TooLarge(code string, message string) *Error {
+	return &Error{Kind: KindTooLarge, Code: code, Message: message}
+}
+
+func UnsupportedMedia(code string, message string) *Error {
+	return &Error{Kind: KindUnsupportedMedia, Code: code, Message: message}
+}
+
+func (e *Error) Error() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	return string(e.Kind)
+}
+
+// Is reports whether target is a kind sentinel for e's kind, or an Error with the same code.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	if !ok || t.Kind != e.Kind {
+		return false
+	}
+	return t.Code == "" || t.Code == e.Code
+}
+
+// ErrorCode returns the machine-readable code of e.
+func (e *Error) ErrorCode() string {
+	if e.Code != "" {
+		return e.Code
+	}
+	return string(e.Kind)
+}
@@ -2,112 +2,173 @@ package exception
 
 import (
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"strings"
 
 	"github.com/dihanto/gosnap/model/web/response"
 	"github.com/go-playground/validator/v10"
 	"github.com/labstack/echo/v4"
-	"golang.org/x/crypto/bcrypt"
 )
 
+const problemContentType = "application/problem+json"
+
+var kindStatus = map[Kind]int{
+	KindNotFound:         http.StatusNotFound,
+	KindConflict:         http.StatusConflict,
+	KindForbidden:        http.StatusForbidden,
+	KindUnauthorized:     http.StatusUnauthorized,
+	KindValidation:       http.StatusBadRequest,
+	KindRateLimited:      http.StatusTooManyRequests,
+	KindTooLarge:         http.StatusRequestEntityTooLarge,
+	KindUnsupportedMedia: http.StatusUnsupportedMediaType,
+}
+
+// ErrorHandler reports err to the client as an RFC 7807 problem. Errors that aren't an
+// *Error, a validation error or an echo HTTP error are logged and hidden behind a
+// generic 500 so database and driver messages never reach clients.
 func ErrorHandler(err error, ctx echo.Context) {
+	if ctx.Response().Committed {
+		return
+	}
+
 	if validationError(err, ctx) {
 		return
 	}
 
-	if err == bcrypt.ErrMismatchedHashAndPassword {
-		errPasswordDoNotMatch(err, ctx)
+	var appError *Error
+	if errors.As(err, &appError) {
+		domainError(appError, ctx)
+		return
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		domainError(NotFound("not_found", "Not Found"), ctx)
 		return
 	}
-	if strings.Contains(err.Error(), "token is expired") {
-		unauthorizedError(err, ctx)
+	var maxBytesError *http.MaxBytesError
+	if errors.As(err, &maxBytesError) {
+		domainError(TooLarge("upload_too_large", "upload exceeds the maximum allowed size"), ctx)
 		return
 	}
-	if strings.Contains(err.Error(), "unauthorized") {
-		unauthorizedError(err, ctx)
+	if decodeError(err, ctx) {
 		return
 	}
-	if err == sql.ErrNoRows {
-		notFoundError(err, ctx)
+	if httpError(err, ctx) {
 		return
 	}
 
 	internalServerError(err, ctx)
 }
 
-func notFoundError(err error, ctx echo.Context) {
-	webResponse := response.WebResponse{
-		Status:  http.StatusNotFound,
-		Message: "Not Found",
+func domainError(err *Error, ctx echo.Context) {
+	status, ok := kindStatus[err.Kind]
+	if !ok {
+		status = http.StatusInternalServerError
 	}
 
-	ctx.JSON(http.StatusNotFound, webResponse)
+	writeProblem(ctx, status, err.ErrorCode(), err.Message)
 }
-func unauthorizedError(err error, ctx echo.Context) {
-	webResponse := response.WebResponse{
-		Status:  http.StatusUnauthorized,
-		Message: "Unauthorized",
+
+func httpError(err error, ctx echo.Context) bool {
+	var he *echo.HTTPError
+	var bindingError *echo.BindingError
+	if errors.As(err, &bindingError) {
+		he = bindingError.HTTPError
+	} else if !errors.As(err, &he) {
+		return false
+	}
+
+	code := statusCode(he.Code)
+	if bindingError != nil {
+		code = "invalid_request_body"
 	}
 
-	ctx.JSON(http.StatusUnauthorized, webResponse)
+	writeProblem(ctx, he.Code, code, fmt.Sprint(he.Message))
+	return true
 }
 
-func internalServerError(err interface{}, ctx echo.Context) {
-	var dataError string
-	if err != nil {
-		dataError = err.(error).Error()
-	}
-	webResponse := response.WebResponse{
-		Status:  http.StatusInternalServerError,
-		Message: dataError,
+// decodeError reports request bodies that aren't valid JSON for the target struct.
+func decodeError(err error, ctx echo.Context) bool {
+	var syntaxError *json.SyntaxError
+	var typeError *json.UnmarshalTypeError
+	if !errors.As(err, &syntaxError) && !errors.As(err, &typeError) && !errors.Is(err, io.ErrUnexpectedEOF) && err != io.EOF {
+		return false
 	}
 
-	ctx.JSON(http.StatusInternalServerError, webResponse)
+	writeProblem(ctx, http.StatusBadRequest, "invalid_request_body", "request body is not valid JSON")
+	return true
+}
+
+func internalServerError(err error, ctx echo.Context) {
+	ctx.Logger().Errorf("request_id=%s %s %s: %v", requestId(ctx), ctx.Request().Method, ctx.Request().URL.Path, err)
+
+	writeProblem(ctx, http.StatusInternalServerError, statusCode(http.StatusInternalServerError), "")
 }
 
 func validationError(errs interface{}, ctx echo.Context) bool {
 	exception, ok := errs.(validator.ValidationErrors)
-	if ok {
-		for _, err := range exception {
-			var message string
-			fieldName := strings.ToLower(err.Field())
-			tag := err.ActualTag()
-			switch tag {
-			case "required":
-				message = fmt.Sprintf("%s is required", fieldName)
-			case "email":
-				message = fmt.Sprintf("%s is not a valid email", fieldName)
-			case "email_uniq":
-				message = fmt.Sprintf("%s must be unique", fieldName)
-			case "username_uniq":
-				message = fmt.Sprintf("%s must be unique", fieldName)
-			case "follow":
-				message = fmt.Sprintf("%s you have already following", fieldName)
-			case "min":
-				message = fmt.Sprintf("%s must be at least %s characters long", fieldName, err.Param())
-			case "gt":
-				message = fmt.Sprintf("%s must be greater than %s", fieldName, err.Param())
-			default:
-				message = fmt.Sprintf("validation error for %s: %s", fieldName, tag)
-			}
-			webResponse := response.WebResponse{
-				Status:  http.StatusBadRequest,
-				Message: message,
-			}
-			ctx.JSON(http.StatusBadRequest, webResponse)
+	if !ok || len(exception) == 0 {
+		return false
+	}
+
+	translator := findTranslator(ctx.Request().Header.Get("Accept-Language"))
+	fields := make([]response.ValidationError, 0, len(exception))
+	for _, err := range exception {
+		message := err.Error()
+		if translator != nil {
+			message = err.Translate(translator)
 		}
-		return true
+		fields = append(fields, response.ValidationError{
+			Field:   fieldPath(err),
+			Rule:    err.Tag(),
+			Param:   err.Param(),
+			Message: message,
+		})
+	}
+
+	problem := newProblem(ctx, http.StatusBadRequest, string(KindValidation), "request has invalid fields")
+	problem.Errors = fields
+	sendProblem(ctx, problem)
+	return true
+}
+
+func writeProblem(ctx echo.Context, status int, code string, detail string) {
+	sendProblem(ctx, newProblem(ctx, status, code, detail))
+}
+
+func newProblem(ctx echo.Context, status int, code string, detail string) response.Problem {
+	return response.Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    detail,
+		Instance:  ctx.Request().URL.Path,
+		Code:      code,
+		RequestId: requestId(ctx),
 	}
-	return false
 }
 
-func errPasswordDoNotMatch(err error, ctx echo.Context) {
-	webResponse := response.WebResponse{
-		Status:  http.StatusBadRequest,
-		Message: "Password does not match",
+func sendProblem(ctx echo.Context, problem response.Problem) {
+	ctx.Response().Header().Set(echo.HeaderContentType, problemContentType)
+	if ctx.Request().Method == http.MethodHead {
+		ctx.NoContent(problem.Status)
+		return
 	}
+	ctx.JSON(problem.Status, problem)
+}
+
+func requestId(ctx echo.Context) string {
+	id := ctx.Response().Header().Get(echo.HeaderXRequestID)
+	if id == "" {
+		id = ctx.Request().Header.Get(echo.HeaderXRequestID)
+	}
+	return id
+}
 
-	ctx.JSON(http.StatusBadRequest, webResponse)
+// statusCode turns an HTTP status into a code such as "method_not_allowed".
+func statusCode(status int) string {
+	return strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_")
 }
@@ -0,0 +1,117 @@
+package exception
+
+import (
+	"reflect"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/id"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	en_translations "github.com/go-playground/validator/v10/translations/en"
+	id_translations "github.com/go-playground/validator/v10/translations/id"
+)
+
+// customMessages holds the messages of the validation tags gosnap registers itself, per locale.
+var customMessages = map[string]map[string]string{
+	"en": {
+		"email_uniq":    "{0} is already taken",
+		"username_uniq": "{0} is already taken",
+	},
+	"id": {
+		"email_uniq":    "{0} sudah digunakan",
+		"username_uniq": "{0} sudah digunakan",
+	},
+}
+
+var translators *ut.UniversalTranslator
+
+// InitValidation makes validate report fields by their JSON names and registers the
+// English and Indonesian validation messages. ErrorHandler picks the language from
+// the Accept-Language header, falling back to English.
+func InitValidation(validate *validator.Validate) error {
+	validate.RegisterTagNameFunc(jsonFieldName)
+
+	english := en.New()
+	translators = ut.New(english, english, id.New())
+
+	registerDefaults := map[string]func(*validator.Validate, ut.Translator) error{
+		"en": en_translations.RegisterDefaultTranslations,
+		"id": id_translations.RegisterDefaultTranslations,
+	}
+	for locale, register := range registerDefaults {
+		translator, _ := translators.GetTranslator(locale)
+		err := register(validate, translator)
+		if err != nil {
+			return err
+		}
+
+		for tag, message := range customMessages[locale] {
+			err = validate.RegisterTranslation(tag, translator, registerMessage(tag, message), translateMessage)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func registerMessage(tag string, message string) validator.RegisterTranslationsFunc {
+	return func(translator ut.Translator) error {
+		return translator.Add(tag, message, true)
+	}
+}
+
+func translateMessage(translator ut.Translator, err validator.FieldError) string {
+	message, translateErr := translator.T(err.Tag(), err.Field())
+	if translateErr != nil {
+		return err.Error()
+	}
+	return message
+}
+
+// jsonFieldName names a struct field after its JSON key, or its lower camel case
+// Go name for fields that aren't read from JSON.
+func jsonFieldName(field reflect.StructField) string {
+	name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+	if name != "" && name != "-" {
+		return name
+	}
+
+	first, size := utf8.DecodeRuneInString(field.Name)
+	return string(unicode.ToLower(first)) + field.Name[size:]
+}
+
+// findTranslator returns the translator for the first supported language in an
+// Accept-Language header, or nil when InitValidation wasn't called.
+func findTranslator(acceptLanguage string) ut.Translator {
+	if translators == nil {
+		return nil
+	}
+
+	var locales []string
+	for _, language := range strings.Split(acceptLanguage, ",") {
+		language = strings.TrimSpace(strings.SplitN(language, ";", 2)[0])
+		if language == "" {
+			continue
+		}
+		language = strings.ToLower(strings.ReplaceAll(language, "-", "_"))
+		locales = append(locales, language, strings.SplitN(language, "_", 2)[0])
+	}
+
+	translator, _ := translators.FindTranslator(locales...)
+	return translator
+}
+
+// fieldPath turns a validator namespace such as "UserRegister.email" into the path
+// of the field inside the request body, "email".
+func fieldPath(err validator.FieldError) string {
+	namespace := err.Namespace()
+	if i := strings.Index(namespace, "."); i >= 0 {
+		return namespace[i+1:]
+	}
+	return namespace
+}
@@ -0,0 +1,70 @@
+package helper
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/dihanto/gosnap/model/domain"
+)
+
+// maxEntityLength matches the size, in characters, of the username and hashtag name columns.
+const maxEntityLength = 100
+
+// ParseEntities finds the @username mentions and #hashtags in text. A mention or hashtag
+// starts at the beginning of the text or after a character that can't be part of one, so
+// email addresses and URL fragments are skipped. Hashtags are lowercased and need at least
+// one letter; mentions keep the username as written and are resolved to users when stored.
+func ParseEntities(text string) []domain.Entity {
+	runes := []rune(text)
+	entities := []domain.Entity{}
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '@' && runes[i] != '#' {
+			continue
+		}
+		if i > 0 && (isEntityRune(runes[i-1]) || runes[i-1] == '@' || runes[i-1] == '#') {
+			continue
+		}
+
+		end := i + 1
+		for end < len(runes) && (isEntityRune(runes[end]) || runes[i] == '@' && runes[end] == '.') {
+			end++
+		}
+		// a sentence ending right after a mention keeps its full stop
+		for end > i+1 && runes[end-1] == '.' {
+			end--
+		}
+		name := string(runes[i+1 : end])
+		if name == "" || end-i-1 > maxEntityLength {
+			continue
+		}
+
+		entity := domain.Entity{
+			Start: i,
+			End:   end,
+		}
+		if runes[i] == '@' {
+			entity.Type = domain.EntityMention
+			entity.Username = name
+		} else {
+			if strings.IndexFunc(name, unicode.IsLetter) < 0 {
+				continue
+			}
+			entity.Type = domain.EntityHashtag
+			entity.Tag = strings.ToLower(name)
+		}
+		entities = append(entities, entity)
+		i = end - 1
+	}
+
+	return entities
+}
+
+// NormalizeTag turns a hashtag as typed by a client, with or without its #, into the
+// form ParseEntities stores it in.
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimPrefix(tag, "#"))
+}
+
+func isEntityRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,53 @@
+package helper
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dihanto/gosnap/model/domain"
+)
+
+func mention(start int, end int, username string) domain.Entity {
+	return domain.Entity{Type: domain.EntityMention, Start: start, End: end, Username: username}
+}
+
+func hashtag(start int, end int, tag string) domain.Entity {
+	return domain.Entity{Type: domain.EntityHashtag, Start: start, End: end, Tag: tag}
+}
+
+func TestParseEntities(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []domain.Entity
+	}{
+		{"empty", "", []domain.Entity{}},
+		{"mention", "hello @alice!", []domain.Entity{mention(6, 12, "alice")}},
+		{"mention at start", "@alice hi", []domain.Entity{mention(0, 6, "alice")}},
+		{"mention with dots", "@john.doe rocks", []domain.Entity{mention(0, 9, "john.doe")}},
+		{"trailing full stop", "thanks @alice.", []domain.Entity{mention(7, 13, "alice")}},
+		{"trailing full stops", "thanks @alice...", []domain.Entity{mention(7, 13, "alice")}},
+		{"email address", "mail bob@example.com", []domain.Entity{}},
+		{"hashtags lowercased", "see #Go and #GoLang", []domain.Entity{hashtag(4, 7, "go"), hashtag(12, 19, "golang")}},
+		{"hashtag stops at dot", "#tag.", []domain.Entity{hashtag(0, 4, "tag")}},
+		{"url fragment", "example.com/page#section", []domain.Entity{}},
+		{"digit only hashtag", "#2024", []domain.Entity{}},
+		{"hashtag with digits", "#2024recap", []domain.Entity{hashtag(0, 10, "2024recap")}},
+		{"doubled markers", "@@alice ##tag", []domain.Entity{}},
+		{"bare markers", "@ # @. #!", []domain.Entity{}},
+		{"adjacent entities", "#one#two @a@b", []domain.Entity{hashtag(0, 4, "one"), mention(9, 11, "a")}},
+		{"unicode offsets", "#café and @名前", []domain.Entity{hashtag(0, 5, "café"), mention(10, 13, "名前")}},
+		{"longest name", "#" + strings.Repeat("é", 100), []domain.Entity{hashtag(0, 101, strings.Repeat("é", 100))}},
+		{"name too long", "#" + strings.Repeat("é", 101), []domain.Entity{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := ParseEntities(test.text)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("ParseEntities(%q) = %+v, want %+v", test.text, got, test.want)
+			}
+		})
+	}
+}
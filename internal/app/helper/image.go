@@ -0,0 +1,102 @@
+package helper
+
+import (
+	"encoding/base64"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/dihanto/gosnap/internal/app/exception"
+	_ "golang.org/x/image/webp"
+)
+
+// maxImagePixels guards against decompression bombs: small files that decode
+// into enormous bitmaps.
+const maxImagePixels = 50_000_000
+
+var ErrUnsupportedImage = exception.UnsupportedMedia("unsupported_image", "unsupported image type")
+var ErrCorruptImage = exception.Validation("corrupt_image", "image is corrupt or truncated")
+
+var imageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// DecodeBase64Image decodes a base64 image, optionally wrapped in a data URI.
+func DecodeBase64Image(data string) ([]byte, error) {
+	if strings.HasPrefix(data, "data:") {
+		comma := strings.IndexByte(data, ',')
+		if comma < 0 {
+			return nil, ErrCorruptImage
+		}
+		data = data[comma+1:]
+	}
+
+	image, err := base64.StdEncoding.DecodeString(strings.TrimSpace(data))
+	if err != nil {
+		return nil, ErrCorruptImage
+	}
+
+	return image, nil
+}
+
+// SniffImage detects the content type of an image from its leading bytes,
+// ignoring whatever the client claimed, and rewinds the reader.
+func SniffImage(reader io.ReadSeeker, allowedTypes []string) (string, error) {
+	header := make([]byte, 512)
+	n, err := io.ReadFull(reader, header)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
+	}
+
+	_, err = reader.Seek(0, io.SeekStart)
+	if err != nil {
+		return "", err
+	}
+
+	contentType := http.DetectContentType(header[:n])
+	if _, ok := imageExtensions[contentType]; !ok {
+		return "", ErrUnsupportedImage
+	}
+	for _, allowedType := range allowedTypes {
+		if allowedType == contentType {
+			return contentType, nil
+		}
+	}
+
+	return "", ErrUnsupportedImage
+}
+
+// VerifyImage fully decodes the image to reject corrupt or truncated uploads
+// and rewinds the reader.
+func VerifyImage(reader io.ReadSeeker) error {
+	config, _, err := image.DecodeConfig(reader)
+	if err != nil {
+		return ErrCorruptImage
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > maxImagePixels {
+		return ErrCorruptImage
+	}
+
+	_, err = reader.Seek(0, io.SeekStart)
+	if err != nil {
+		return err
+	}
+	_, _, err = image.Decode(reader)
+	if err != nil {
+		return ErrCorruptImage
+	}
+
+	_, err = reader.Seek(0, io.SeekStart)
+	return err
+}
+
+func ImageExtension(contentType string) string {
+	return imageExtensions[contentType]
+}
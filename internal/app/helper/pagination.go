@@ -0,0 +1,62 @@
+package helper
+
+import (
+	"encoding/base64"
+	"encoding/json"
+
+	"github.com/dihanto/gosnap/internal/app/exception"
+	"github.com/dihanto/gosnap/model/domain"
+	"github.com/dihanto/gosnap/model/web/request"
+)
+
+const DefaultPageLimit = 20
+
+var ErrInvalidCursor = exception.Validation("invalid_cursor", "invalid cursor")
+
+// NewPage turns the cursor and limit sent by a client into a repository page,
+// applying defaultLimit when the client didn't ask for a page size.
+func NewPage(page request.Page, defaultLimit int) (domain.Page, error) {
+	cursor, err := DecodeCursor(page.Cursor)
+	if err != nil {
+		return domain.Page{}, err
+	}
+
+	limit := page.Limit
+	if limit == 0 {
+		limit = defaultLimit
+	}
+
+	return domain.Page{
+		Cursor: cursor,
+		Limit:  limit,
+	}, nil
+}
+
+// EncodeCursor makes a cursor opaque to clients. The zero cursor, meaning
+// there are no more rows, encodes to an empty string.
+func EncodeCursor(cursor domain.Cursor) string {
+	if cursor.IsZero() {
+		return ""
+	}
+
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func DecodeCursor(encoded string) (domain.Cursor, error) {
+	cursor := domain.Cursor{}
+	if encoded == "" {
+		return cursor, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return cursor, ErrInvalidCursor
+	}
+	err = json.Unmarshal(data, &cursor)
+	if err != nil || cursor.IsZero() {
+		return domain.Cursor{}, ErrInvalidCursor
+	}
+
+	return cursor, nil
+}
@@ -0,0 +1,24 @@
+package helper
+
+import "strings"
+
+// PrefixQuery turns the words of q into a to_tsquery expression that matches every word
+// as a prefix, as in "sun:* & set:*", so names are found while they are still being typed.
+// Everything but letters, digits and underscores separates words, so the result is
+// always a valid query.
+func PrefixQuery(q string) string {
+	words := strings.FieldsFunc(strings.ToLower(q), func(r rune) bool {
+		return !isEntityRune(r)
+	})
+	for i, word := range words {
+		words[i] = word + ":*"
+	}
+
+	return strings.Join(words, " & ")
+}
+
+// LikePrefix returns a LIKE pattern matching the strings that start with prefix.
+func LikePrefix(prefix string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return replacer.Replace(prefix) + "%"
+}
@@ -1,20 +1,93 @@
 package helper
 
 import (
+	"context"
 	"database/sql"
-	"log"
 )
 
-func CommitOrRollback(tx *sql.Tx, err *error) {
-	if *err != nil {
-		errRollback := tx.Rollback()
-		if errRollback != nil {
-			log.Println(errRollback)
-		}
-	} else {
-		errCommit := tx.Commit()
-		if errCommit != nil {
-			log.Println(errCommit)
-		}
+type txKey struct{}
+
+// ReadOnly starts transactions for repository methods that only query.
+var ReadOnly = &sql.TxOptions{ReadOnly: true}
+
+// Tx is the transaction a repository method runs its statements in. When the context
+// already carries a transaction, started by a TxManager, the method joins it and
+// Commit and Rollback are left to the TxManager.
+type Tx struct {
+	*sql.Tx
+	joined bool
+}
+
+// BeginTx joins the transaction carried by ctx or, when there is none, starts one on
+// database with opts. Callers defer Rollback and return the result of Commit.
+func BeginTx(ctx context.Context, database *sql.DB, opts *sql.TxOptions) (*Tx, error) {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return &Tx{Tx: tx, joined: true}, nil
+	}
+
+	tx, err := database.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Tx{Tx: tx}, nil
+}
+
+func (tx *Tx) Commit() error {
+	if tx.joined {
+		return nil
+	}
+	return tx.Tx.Commit()
+}
+
+// Rollback undoes a transaction that wasn't committed yet. It does nothing after Commit,
+// so it can always be deferred.
+func (tx *Tx) Rollback() error {
+	if tx.joined {
+		return nil
+	}
+	return tx.Tx.Rollback()
+}
+
+// TxManager runs several repository calls as one unit of work: repositories called with
+// the context given to fn join its transaction instead of starting their own.
+type TxManager struct {
+	Database *sql.DB
+}
+
+func NewTxManager(database *sql.DB) *TxManager {
+	return &TxManager{
+		Database: database,
 	}
 }
+
+// WithinTx runs fn in a transaction that is committed when fn returns nil and rolled
+// back otherwise. Called inside another unit of work, fn simply joins it.
+func (manager *TxManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return manager.run(ctx, nil, fn)
+}
+
+// WithinReadOnlyTx is WithinTx for units of work that only query, so that they read one
+// consistent snapshot.
+func (manager *TxManager) WithinReadOnlyTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return manager.run(ctx, &sql.TxOptions{ReadOnly: true, Isolation: sql.LevelRepeatableRead}, fn)
+}
+
+func (manager *TxManager) run(ctx context.Context, opts *sql.TxOptions, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := manager.Database.BeginTx(ctx, opts)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = fn(context.WithValue(ctx, txKey{}, tx))
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,35 @@
+package helper
+
+import (
+	"io"
+	"os"
+
+	"github.com/dihanto/gosnap/internal/app/exception"
+)
+
+var ErrUploadTooLarge = exception.TooLarge("upload_too_large", "upload exceeds the maximum allowed size")
+
+// SpoolUpload streams an uploaded file to a temporary file on disk so large
+// uploads never sit in memory. The caller owns the returned file and must
+// close and remove it.
+func SpoolUpload(reader io.Reader, maxBytes int64) (*os.File, int64, error) {
+	file, err := os.CreateTemp("", "gosnap-upload-*")
+	if err != nil {
+		return nil, 0, err
+	}
+
+	size, err := io.Copy(file, io.LimitReader(reader, maxBytes+1))
+	if err == nil && size > maxBytes {
+		err = ErrUploadTooLarge
+	}
+	if err == nil {
+		_, err = file.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return nil, 0, err
+	}
+
+	return file, size, nil
+}
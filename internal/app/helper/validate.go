@@ -2,135 +2,62 @@ package helper
 
 import (
 	"context"
+	"database/sql"
 	"log"
 
-	"github.com/dihanto/gosnap/internal/app/config"
 	"github.com/go-playground/validator/v10"
-	"github.com/google/uuid"
 )
 
-func ValidateEmailUniq(field validator.FieldLevel) bool {
-	value := field.Field().Interface().(string)
-
-	conn, _ := config.InitDatabaseConnection()
-	defer conn.Close()
-
-	ctx := context.Background()
-	query := "SELECT email FROM users"
-	rows, err := conn.QueryContext(ctx, query)
-	if err != nil {
-		log.Println(err)
-	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var email string
-		err = rows.Scan(&email)
-		if err != nil {
-			log.Println(err)
-		}
-		if value == email {
-			return false
-		}
-	}
-
-	return true
-
+// DatabaseValidator holds the validations that need to look rows up in the database.
+// They only give early, per-field feedback: the unique constraints behind them are
+// what keeps concurrent requests from inserting duplicates. When a lookup fails the
+// value is let through and the constraint decides.
+type DatabaseValidator struct {
+	Database *sql.DB
 }
 
-func ValidateUsernameUniq(field validator.FieldLevel) bool {
-	value := field.Field().Interface().(string)
-
-	conn, _ := config.InitDatabaseConnection()
-	defer conn.Close()
-
-	query := "SELECT username FROM users"
-	ctx := context.Background()
-	rows, err := conn.QueryContext(ctx, query)
-	if err != nil {
-		log.Println(err)
-	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var username string
-		err = rows.Scan(&username)
-		if err != nil {
-			log.Println(err)
-		}
-		if value == username {
-			return false
-		}
+func NewDatabaseValidator(database *sql.DB) *DatabaseValidator {
+	return &DatabaseValidator{
+		Database: database,
 	}
-	return true
 }
 
-func ValidateOneUserOneLike(field validator.FieldLevel) bool {
-	value := field.Field().Interface().(uuid.UUID)
-	photoId := field.Param()
-	var likeId int
-
-	conn, _ := config.InitDatabaseConnection()
-	defer conn.Close()
-
-	queryLikes := "SELECT id FROM likes WHERE photo_id=$1"
-	err := conn.QueryRowContext(context.Background(), queryLikes, photoId).Scan(&likeId)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	queryLikeDetail := "SELECT user_id FROM like_details WHERE like_id=$1"
-	rows, err := conn.QueryContext(context.Background(), queryLikeDetail, likeId)
-	if err != nil {
-		log.Fatalln(err)
+// Register adds the email_uniq and username_uniq tags to validate.
+// They use the context given to StructCtx or VarCtx for their queries.
+func (databaseValidator *DatabaseValidator) Register(validate *validator.Validate) error {
+	validations := map[string]validator.FuncCtx{
+		"email_uniq":    databaseValidator.ValidateEmailUniq,
+		"username_uniq": databaseValidator.ValidateUsernameUniq,
 	}
-	defer rows.Close()
-
-	var userId uuid.UUID
-	for rows.Next() {
-		err = rows.Scan(&userId)
+	for tag, validation := range validations {
+		err := validate.RegisterValidationCtx(tag, validation)
 		if err != nil {
-			log.Fatalln(err)
-		}
-		if value == userId {
-			return false
+			return err
 		}
 	}
 
-	return true
+	return nil
 }
 
-func ValidateUserNotFollowTwice(field validator.FieldLevel) bool {
-	value := field.Field().Interface().(string)
-	targetUsername := field.Param()
-	var followId int
-
-	conn, _ := config.InitDatabaseConnection()
-	defer conn.Close()
+// ValidateEmailUniq checks that no account uses the email yet.
+func (databaseValidator *DatabaseValidator) ValidateEmailUniq(ctx context.Context, field validator.FieldLevel) bool {
+	query := "SELECT EXISTS (SELECT 1 FROM users WHERE email=$1)"
+	return !databaseValidator.exists(ctx, query, field.Field().String())
+}
 
-	queryFollowers := "SELECT id FROM followers WHERE username=$1"
-	err := conn.QueryRowContext(context.Background(), queryFollowers, targetUsername).Scan(&followId)
-	if err != nil {
-		log.Fatalln(err)
-	}
+// ValidateUsernameUniq checks that no account uses the username yet.
+func (databaseValidator *DatabaseValidator) ValidateUsernameUniq(ctx context.Context, field validator.FieldLevel) bool {
+	query := "SELECT EXISTS (SELECT 1 FROM users WHERE username=$1)"
+	return !databaseValidator.exists(ctx, query, field.Field().String())
+}
 
-	queryFollowerDetails := "SELECT follower_name FROM follower_details WHERE follow_id=$1"
-	rows, err := conn.QueryContext(context.Background(), queryFollowerDetails, followId)
+func (databaseValidator *DatabaseValidator) exists(ctx context.Context, query string, args ...interface{}) bool {
+	var exists bool
+	err := databaseValidator.Database.QueryRowContext(ctx, query, args...).Scan(&exists)
 	if err != nil {
-		log.Fatalln(err)
-	}
-	defer rows.Close()
-
-	var followerUsername string
-	for rows.Next() {
-		err = rows.Scan(&followerUsername)
-		if err != nil {
-			log.Fatalln(err)
-		}
-		if value == followerUsername {
-			return false
-		}
+		log.Println(err)
+		return false
 	}
 
-	return true
+	return exists
 }
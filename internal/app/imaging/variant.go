@@ -0,0 +1,188 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"path"
+	"strings"
+
+	_ "image/gif"
+
+	_ "golang.org/x/image/webp"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+const jpegQuality = 85
+
+// Variant describes one rendition of an uploaded photo. Size is the longest
+// edge in pixels (0 keeps the original dimensions); square variants are center
+// cropped before being scaled.
+type Variant struct {
+	Name   string
+	Size   int
+	Square bool
+}
+
+var (
+	Thumbnail = Variant{Name: "thumbnail", Size: 320, Square: true}
+	Medium    = Variant{Name: "medium", Size: 1080}
+	Original  = Variant{Name: "original"}
+)
+
+var Variants = []Variant{Thumbnail, Medium, Original}
+
+// VariantKey is the object key the variant of the photo stored under sourceKey is written
+// to. It only depends on sourceKey, so a photo's URLs are known as soon as it is uploaded.
+// The extension follows Render: PNG sources stay PNG, everything else becomes JPEG.
+func VariantKey(sourceKey string, variant Variant) string {
+	extension := ".jpg"
+	if path.Ext(sourceKey) == ".png" {
+		extension = ".png"
+	}
+	name := strings.TrimSuffix(path.Base(sourceKey), path.Ext(sourceKey))
+	return "photos/" + name + "/" + variant.Name + extension
+}
+
+type Rendition struct {
+	Variant     Variant
+	Data        []byte
+	ContentType string
+}
+
+// Render decodes an image, applies its EXIF orientation and encodes every
+// variant. Re-encoding drops all metadata (EXIF, GPS, ICC) from the output.
+// PNG sources stay PNG to keep transparency, everything else becomes JPEG.
+func Render(data []byte, variants []Variant) ([]Rendition, error) {
+	decoded, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	source := orient(toNRGBA(decoded), Orientation(data))
+
+	contentType := "image/jpeg"
+	if http.DetectContentType(data) == "image/png" {
+		contentType = "image/png"
+	}
+
+	var renditions []Rendition
+	for _, variant := range variants {
+		var encoded bytes.Buffer
+		img := resize(source, variant)
+		if contentType == "image/png" {
+			err = png.Encode(&encoded, img)
+		} else {
+			err = jpeg.Encode(&encoded, flatten(img), &jpeg.Options{Quality: jpegQuality})
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		renditions = append(renditions, Rendition{
+			Variant:     variant,
+			Data:        encoded.Bytes(),
+			ContentType: contentType,
+		})
+	}
+
+	return renditions, nil
+}
+
+func resize(source *image.NRGBA, variant Variant) *image.NRGBA {
+	bounds := source.Bounds()
+	if variant.Square {
+		side := bounds.Dx()
+		if bounds.Dy() < side {
+			side = bounds.Dy()
+		}
+		x := bounds.Min.X + (bounds.Dx()-side)/2
+		y := bounds.Min.Y + (bounds.Dy()-side)/2
+		bounds = image.Rect(x, y, x+side, y+side)
+	}
+
+	width, height := bounds.Dx(), bounds.Dy()
+	if variant.Size > 0 && (width > variant.Size || height > variant.Size) {
+		if width >= height {
+			height = height * variant.Size / width
+			width = variant.Size
+		} else {
+			width = width * variant.Size / height
+			height = variant.Size
+		}
+	}
+	if width < 1 {
+		width = 1
+	}
+	if height < 1 {
+		height = 1
+	}
+
+	if width == bounds.Dx() && height == bounds.Dy() {
+		return source.SubImage(bounds).(*image.NRGBA)
+	}
+
+	resized := image.NewNRGBA(image.Rect(0, 0, width, height))
+	xdraw.CatmullRom.Scale(resized, resized.Bounds(), source, bounds, xdraw.Src, nil)
+	return resized
+}
+
+// flatten composites the image onto white since JPEG has no alpha channel.
+func flatten(img *image.NRGBA) image.Image {
+	flat := image.NewRGBA(img.Bounds())
+	draw.Draw(flat, flat.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), img, img.Bounds().Min, draw.Over)
+	return flat
+}
+
+func toNRGBA(img image.Image) *image.NRGBA {
+	bounds := img.Bounds()
+	converted := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(converted, converted.Bounds(), img, bounds.Min, draw.Src)
+	return converted
+}
+
+// orient rotates and mirrors the image so it displays upright, following the
+// eight EXIF orientation values.
+func orient(img *image.NRGBA, orientation int) *image.NRGBA {
+	if orientation <= 1 || orientation > 8 {
+		return img
+	}
+
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	dstWidth, dstHeight := width, height
+	if orientation >= 5 {
+		dstWidth, dstHeight = height, width
+	}
+
+	oriented := image.NewNRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+	for y := 0; y < dstHeight; y++ {
+		for x := 0; x < dstWidth; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2:
+				sx, sy = width-1-x, y
+			case 3:
+				sx, sy = width-1-x, height-1-y
+			case 4:
+				sx, sy = x, height-1-y
+			case 5:
+				sx, sy = y, x
+			case 6:
+				sx, sy = y, height-1-x
+			case 7:
+				sx, sy = width-1-y, height-1-x
+			case 8:
+				sx, sy = width-1-y, x
+			}
+			src := img.PixOffset(sx, sy)
+			dst := oriented.PixOffset(x, y)
+			copy(oriented.Pix[dst:dst+4], img.Pix[src:src+4])
+		}
+	}
+
+	return oriented
+}
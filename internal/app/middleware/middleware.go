@@ -1,16 +1,72 @@
 package middleware
 
 import (
+	"errors"
+	"fmt"
+	"net/url"
 	"os"
+	"path"
+	"strings"
 
+	"github.com/dihanto/gosnap/internal/app/auth"
+	"github.com/dihanto/gosnap/internal/app/storage"
 	echojwt "github.com/labstack/echo-jwt/v4"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 )
 
-var Auth = echojwt.WithConfig(echojwt.Config{
-	SigningKey: []byte("snapsecret"),
-})
+// Auth rejects requests without a valid access token and stores the caller as an
+// auth.Principal in the request context. It is set up by InitAuth.
+var Auth echo.MiddlewareFunc
+
+func InitAuth(tokens *auth.TokenManager) {
+	Auth = echojwt.WithConfig(echojwt.Config{
+		ParseTokenFunc: func(ctx echo.Context, token string) (interface{}, error) {
+			return tokens.Parse(token)
+		},
+		SuccessHandler: func(ctx echo.Context) {
+			claims := ctx.Get("user").(*auth.Claims)
+			request := ctx.Request()
+			ctx.SetRequest(request.WithContext(auth.WithPrincipal(request.Context(), claims.Principal())))
+		},
+		ErrorHandler: func(ctx echo.Context, err error) error {
+			if errors.Is(err, auth.ErrUnauthorized) {
+				return err
+			}
+			return fmt.Errorf("%w: %v", auth.ErrUnauthorized, err)
+		},
+	})
+}
+
+// RequireRole only lets through callers holding role or a higher one. It must run after Auth.
+func RequireRole(role string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			principal, err := auth.PrincipalFromContext(ctx.Request().Context())
+			if err != nil {
+				return err
+			}
+			if !principal.HasRole(role) {
+				return auth.ErrForbidden
+			}
+
+			return next(ctx)
+		}
+	}
+}
+
+// HidePrivateObjects keeps a static route serving the files of a local blob store from
+// serving the objects below storage.PrivatePrefix.
+func HidePrivateObjects(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		key, err := url.PathUnescape(ctx.Param("*"))
+		if err != nil || storage.IsPrivate(strings.TrimPrefix(path.Clean("/"+key), "/")) {
+			return echo.ErrNotFound
+		}
+
+		return next(ctx)
+	}
+}
 
 func SnapLogger(router *echo.Echo, logFile *os.File) {
 	router.Use(middleware.LoggerWithConfig(middleware.LoggerConfig{
@@ -4,11 +4,18 @@ import (
 	"context"
 
 	"github.com/dihanto/gosnap/model/domain"
+	"github.com/google/uuid"
 )
 
 type CommentRepository interface {
 	PostComment(ctx context.Context, comment domain.Comment) (domain.Comment, error)
-	GetComment(ctx context.Context) ([]domain.Comment, []domain.User, []domain.Photo, error)
+	GetComment(ctx context.Context, page domain.Page) ([]domain.Comment, []domain.User, []domain.Photo, domain.Cursor, error)
+	GetPhotoComments(ctx context.Context, photoId int, page domain.Page) ([]domain.Comment, []domain.User, domain.Cursor, error)
+	GetReplies(ctx context.Context, commentId int, page domain.Page) ([]domain.Comment, []domain.User, domain.Cursor, error)
+	GetCommentById(ctx context.Context, id int) (domain.Comment, error)
+	PinComment(ctx context.Context, id int, photoId int, maxPinned int) error
+	UnpinComment(ctx context.Context, id int) error
 	UpdateComment(ctx context.Context, comment domain.Comment) (domain.Comment, error)
 	DeleteComment(ctx context.Context, id int) error
+	GetCommentOwner(ctx context.Context, id int) (uuid.UUID, error)
 }
@@ -3,11 +3,11 @@ package repository
 import (
 	"context"
 	"database/sql"
-	"errors"
-	"time"
+	"strconv"
 
 	"github.com/dihanto/gosnap/internal/app/helper"
 	"github.com/dihanto/gosnap/model/domain"
+	"github.com/google/uuid"
 )
 
 type CommentRepositoryImpl struct {
@@ -22,36 +22,54 @@ func NewCommentRepository(database *sql.DB) CommentRepository {
 
 // PostComment is a method to create a new comment entry in the database.
 func (repository *CommentRepositoryImpl) PostComment(ctx context.Context, comment domain.Comment) (domain.Comment, error) {
-	tx, err := repository.Database.Begin()
+	tx, err := helper.BeginTx(ctx, repository.Database, nil)
 	if err != nil {
-		return domain.Comment{}, nil
+		return domain.Comment{}, err
 	}
-	defer helper.CommitOrRollback(tx, &err)
-
-	comment.CreatedAt = int32(time.Now().Unix())
+	defer tx.Rollback()
 
-	query := "INSERT INTO comments (message, photo_id, user_id, created_at) VALUES ($1, $2, $3, $4) RETURNING id"
-	row := tx.QueryRowContext(ctx, query, comment.Message, comment.PhotoId, comment.UserId, comment.CreatedAt)
-	err = row.Scan(&comment.Id)
+	query := "INSERT INTO comments (message, photo_id, user_id, parent_id, depth) SELECT $1, id, $3, NULLIF($4, 0), $5 FROM photos WHERE id=$2 AND deleted_at IS NULL RETURNING id, created_at, updated_at"
+	row := tx.QueryRowContext(ctx, query, comment.Message, comment.PhotoId, comment.UserId, comment.ParentId, comment.Depth)
+	err = row.Scan(&comment.Id, &comment.CreatedAt, &comment.UpdatedAt)
+	if err == sql.ErrNoRows {
+		err = ErrPhotoNotFound
+	}
+	if err != nil {
+		return domain.Comment{}, err
+	}
+	comment.Entities, err = saveEntities(ctx, tx, commentEntityTables, comment.Id, comment.Entities)
 	if err != nil {
 		return domain.Comment{}, err
 	}
 
-	return comment, nil
+	return comment, tx.Commit()
 }
 
-// GetComment is a method to retrieve all comment entries and their associated users and photos from the database.
-func (repository *CommentRepositoryImpl) GetComment(ctx context.Context) ([]domain.Comment, []domain.User, []domain.Photo, error) {
-	tx, err := repository.Database.Begin()
+// GetComment is a method to retrieve a page of comment entries, newest first, and their associated users and photos from the database.
+func (repository *CommentRepositoryImpl) GetComment(ctx context.Context, page domain.Page) ([]domain.Comment, []domain.User, []domain.Photo, domain.Cursor, error) {
+	tx, err := helper.BeginTx(ctx, repository.Database, helper.ReadOnly)
 	if err != nil {
-		return []domain.Comment{}, []domain.User{}, []domain.Photo{}, nil
+		return []domain.Comment{}, []domain.User{}, []domain.Photo{}, domain.Cursor{}, err
 	}
-	defer helper.CommitOrRollback(tx, &err)
+	defer tx.Rollback()
 
-	query := "SELECT comments.id, comments.message, comments.photo_id, comments.user_id, comments.created_at, comments.updated_at, users.id, users.email, users.username, photos.id, photos.title, photos.caption, photos.photo_base64, photos.user_id FROM comments JOIN photos ON comments.photo_id = photos.id JOIN users ON comments.user_id = users.id WHERE comments.deleted_at IS NULL;"
-	rows, err := tx.QueryContext(ctx, query)
+	query := "SELECT comments.id, comments.message, comments.photo_id, COALESCE(comments.parent_id, 0), comments.user_id, comments.created_at, comments.updated_at, users.id, users.email, users.username, photos.id, photos.title, photos.caption, COALESCE(photos.photo_key, ''), COALESCE(photos.thumbnail_key, ''), COALESCE(photos.medium_key, ''), photos.user_id FROM comments JOIN photos ON comments.photo_id = photos.id JOIN users ON comments.user_id = users.id WHERE comments.deleted_at IS NULL AND photos.deleted_at IS NULL"
+	params := []interface{}{}
+	if !page.Cursor.IsZero() {
+		cursorId, errCursor := strconv.Atoi(page.Cursor.Key)
+		if errCursor != nil {
+			err = helper.ErrInvalidCursor
+			return []domain.Comment{}, []domain.User{}, []domain.Photo{}, domain.Cursor{}, err
+		}
+		query += " AND (comments.created_at, comments.id) < ($1, $2)"
+		params = append(params, page.Cursor.Timestamp(), cursorId)
+	}
+	query += " ORDER BY comments.created_at DESC, comments.id DESC LIMIT $" + strconv.Itoa(len(params)+1)
+	params = append(params, page.Limit+1)
+
+	rows, err := tx.QueryContext(ctx, query, params...)
 	if err != nil {
-		return []domain.Comment{}, []domain.User{}, []domain.Photo{}, err
+		return []domain.Comment{}, []domain.User{}, []domain.Photo{}, domain.Cursor{}, err
 	}
 	defer rows.Close()
 
@@ -63,9 +81,9 @@ func (repository *CommentRepositoryImpl) GetComment(ctx context.Context) ([]doma
 		var comment domain.Comment
 		var user domain.User
 		var photo domain.Photo
-		err = rows.Scan(&comment.Id, &comment.Message, &comment.PhotoId, &comment.UserId, &comment.CreatedAt, &comment.UpdatedAt, &user.Id, &user.Email, &user.Username, &photo.Id, &photo.Title, &photo.Caption, &photo.PhotoBase64, &photo.UserId)
+		err = rows.Scan(&comment.Id, &comment.Message, &comment.PhotoId, &comment.ParentId, &comment.UserId, &comment.CreatedAt, &comment.UpdatedAt, &user.Id, &user.Email, &user.Username, &photo.Id, &photo.Title, &photo.Caption, &photo.PhotoKey, &photo.ThumbnailKey, &photo.MediumKey, &photo.UserId)
 		if err != nil {
-			return []domain.Comment{}, []domain.User{}, []domain.Photo{}, err
+			return []domain.Comment{}, []domain.User{}, []domain.Photo{}, domain.Cursor{}, err
 		}
 		user.Id = comment.UserId
 		photo.Id = comment.PhotoId
@@ -73,43 +91,293 @@ func (repository *CommentRepositoryImpl) GetComment(ctx context.Context) ([]doma
 		photos = append(photos, photo)
 		comments = append(comments, comment)
 	}
+	err = rows.Err()
+	if err != nil {
+		return []domain.Comment{}, []domain.User{}, []domain.Photo{}, domain.Cursor{}, err
+	}
+
+	next := domain.Cursor{}
+	if len(comments) > page.Limit {
+		comments, users, photos = comments[:page.Limit], users[:page.Limit], photos[:page.Limit]
+		last := comments[len(comments)-1]
+		next = domain.NewTimeCursor(last.CreatedAt, strconv.Itoa(last.Id))
+	}
+	err = attachCommentEntities(ctx, tx, comments)
+	if err != nil {
+		return []domain.Comment{}, []domain.User{}, []domain.Photo{}, domain.Cursor{}, err
+	}
 
-	return comments, users, photos, nil
+	return comments, users, photos, next, tx.Commit()
+}
+
+// GetPhotoComments is a method to retrieve a page of the comments on a photo that aren't replies, newest first,
+// with their authors and reply counts.
+func (repository *CommentRepositoryImpl) GetPhotoComments(ctx context.Context, photoId int, page domain.Page) ([]domain.Comment, []domain.User, domain.Cursor, error) {
+	tx, err := helper.BeginTx(ctx, repository.Database, helper.ReadOnly)
+	if err != nil {
+		return []domain.Comment{}, []domain.User{}, domain.Cursor{}, err
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	queryPhoto := "SELECT EXISTS (SELECT 1 FROM photos WHERE id=$1 AND deleted_at IS NULL)"
+	err = tx.QueryRowContext(ctx, queryPhoto, photoId).Scan(&exists)
+	if err != nil {
+		return []domain.Comment{}, []domain.User{}, domain.Cursor{}, err
+	}
+	if !exists {
+		return []domain.Comment{}, []domain.User{}, domain.Cursor{}, ErrPhotoNotFound
+	}
+
+	// pinned comments lead the first page and are left out of the pages after it
+	var comments []domain.Comment
+	var users []domain.User
+	if page.Cursor.IsZero() {
+		query := "SELECT " + threadColumns + " FROM comments JOIN users ON comments.user_id = users.id " +
+			"WHERE comments.photo_id = $1 AND comments.pinned_at IS NOT NULL AND comments.deleted_at IS NULL ORDER BY comments.pinned_at, comments.id"
+		rows, err := tx.QueryContext(ctx, query, photoId)
+		if err != nil {
+			return []domain.Comment{}, []domain.User{}, domain.Cursor{}, err
+		}
+		comments, users, err = scanThreadComments(rows)
+		if err != nil {
+			return []domain.Comment{}, []domain.User{}, domain.Cursor{}, err
+		}
+	}
+
+	filter := "comments.photo_id = $1 AND comments.parent_id IS NULL AND comments.pinned_at IS NULL"
+	unpinned, unpinnedUsers, next, err := repository.getThreadPage(ctx, tx, filter, photoId, false, page)
+	if err != nil {
+		return []domain.Comment{}, []domain.User{}, domain.Cursor{}, err
+	}
+	comments, users = append(comments, unpinned...), append(users, unpinnedUsers...)
+	err = attachCommentEntities(ctx, tx, comments)
+	if err != nil {
+		return []domain.Comment{}, []domain.User{}, domain.Cursor{}, err
+	}
+
+	return comments, users, next, tx.Commit()
+}
+
+// GetReplies is a method to retrieve a page of the direct replies to a comment, oldest first,
+// with their authors and reply counts.
+func (repository *CommentRepositoryImpl) GetReplies(ctx context.Context, commentId int, page domain.Page) ([]domain.Comment, []domain.User, domain.Cursor, error) {
+	tx, err := helper.BeginTx(ctx, repository.Database, helper.ReadOnly)
+	if err != nil {
+		return []domain.Comment{}, []domain.User{}, domain.Cursor{}, err
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	queryParent := "SELECT EXISTS (SELECT 1 FROM comments JOIN photos ON photos.id = comments.photo_id WHERE comments.id=$1 AND comments.deleted_at IS NULL AND photos.deleted_at IS NULL)"
+	err = tx.QueryRowContext(ctx, queryParent, commentId).Scan(&exists)
+	if err != nil {
+		return []domain.Comment{}, []domain.User{}, domain.Cursor{}, err
+	}
+	if !exists {
+		return []domain.Comment{}, []domain.User{}, domain.Cursor{}, ErrCommentNotFound
+	}
+
+	filter := "comments.parent_id = $1"
+	comments, users, next, err := repository.getThreadPage(ctx, tx, filter, commentId, true, page)
+	if err != nil {
+		return []domain.Comment{}, []domain.User{}, domain.Cursor{}, err
+	}
+	err = attachCommentEntities(ctx, tx, comments)
+	if err != nil {
+		return []domain.Comment{}, []domain.User{}, domain.Cursor{}, err
+	}
+
+	return comments, users, next, tx.Commit()
+}
+
+// threadColumns are the columns scanThreadComments reads, for comments joined with their authors as users.
+const threadColumns = "comments.id, comments.message, comments.photo_id, COALESCE(comments.parent_id, 0), comments.depth, comments.user_id, comments.created_at, comments.updated_at, " +
+	"(SELECT COUNT(*) FROM comments replies WHERE replies.parent_id = comments.id AND replies.deleted_at IS NULL), comments.like_count, comments.pinned_at, " +
+	"users.email, users.username"
+
+// getThreadPage pages through the comments matching filter, whose only placeholder is bound to id,
+// by (created_at, id), oldest first when ascending is set and newest first otherwise.
+func (repository *CommentRepositoryImpl) getThreadPage(ctx context.Context, tx *helper.Tx, filter string, id int, ascending bool, page domain.Page) ([]domain.Comment, []domain.User, domain.Cursor, error) {
+	order, compare := "DESC", "<"
+	if ascending {
+		order, compare = "ASC", ">"
+	}
+
+	query := "SELECT " + threadColumns + " FROM comments JOIN users ON comments.user_id = users.id WHERE " + filter + " AND comments.deleted_at IS NULL"
+	params := []interface{}{id}
+	if !page.Cursor.IsZero() {
+		cursorId, err := strconv.Atoi(page.Cursor.Key)
+		if err != nil {
+			return nil, nil, domain.Cursor{}, helper.ErrInvalidCursor
+		}
+		query += " AND (comments.created_at, comments.id) " + compare + " ($2, $3)"
+		params = append(params, page.Cursor.Timestamp(), cursorId)
+	}
+	query += " ORDER BY comments.created_at " + order + ", comments.id " + order + " LIMIT $" + strconv.Itoa(len(params)+1)
+	params = append(params, page.Limit+1)
+
+	rows, err := tx.QueryContext(ctx, query, params...)
+	if err != nil {
+		return nil, nil, domain.Cursor{}, err
+	}
+	comments, users, err := scanThreadComments(rows)
+	if err != nil {
+		return nil, nil, domain.Cursor{}, err
+	}
+
+	next := domain.Cursor{}
+	if len(comments) > page.Limit {
+		comments, users = comments[:page.Limit], users[:page.Limit]
+		last := comments[len(comments)-1]
+		next = domain.NewTimeCursor(last.CreatedAt, strconv.Itoa(last.Id))
+	}
+
+	return comments, users, next, nil
+}
+
+// scanThreadComments reads and closes rows selected with threadColumns.
+func scanThreadComments(rows *sql.Rows) ([]domain.Comment, []domain.User, error) {
+	defer rows.Close()
+
+	comments := []domain.Comment{}
+	var users []domain.User
+	for rows.Next() {
+		var comment domain.Comment
+		var user domain.User
+		err := rows.Scan(&comment.Id, &comment.Message, &comment.PhotoId, &comment.ParentId, &comment.Depth, &comment.UserId, &comment.CreatedAt, &comment.UpdatedAt,
+			&comment.ReplyCount, &comment.LikeCount, &comment.PinnedAt, &user.Email, &user.Username)
+		if err != nil {
+			return nil, nil, err
+		}
+		user.Id = comment.UserId
+		comments = append(comments, comment)
+		users = append(users, user)
+	}
+
+	return comments, users, rows.Err()
+}
+
+// PinComment is a method to pin a comment to the top of its photo's comments. The photo is locked
+// while pinned comments are counted, so concurrent pins can't exceed maxPinned.
+func (repository *CommentRepositoryImpl) PinComment(ctx context.Context, id int, photoId int, maxPinned int) error {
+	tx, err := helper.BeginTx(ctx, repository.Database, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var locked int
+	queryLock := "SELECT id FROM photos WHERE id=$1 AND deleted_at IS NULL FOR UPDATE"
+	err = tx.QueryRowContext(ctx, queryLock, photoId).Scan(&locked)
+	if err == sql.ErrNoRows {
+		return ErrPhotoNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	query := "UPDATE comments SET pinned_at=now() WHERE id=$1 AND photo_id=$2 AND parent_id IS NULL AND pinned_at IS NULL AND deleted_at IS NULL"
+	result, err := tx.ExecContext(ctx, query, id, photoId)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		// already pinned
+		return tx.Commit()
+	}
+
+	var pinned int
+	queryCount := "SELECT COUNT(*) FROM comments WHERE photo_id=$1 AND pinned_at IS NOT NULL AND deleted_at IS NULL"
+	err = tx.QueryRowContext(ctx, queryCount, photoId).Scan(&pinned)
+	if err != nil {
+		return err
+	}
+	if pinned > maxPinned {
+		return ErrPinLimitReached
+	}
+
+	return tx.Commit()
+}
+
+// UnpinComment is a method to return a pinned comment to its place among the other comments.
+func (repository *CommentRepositoryImpl) UnpinComment(ctx context.Context, id int) error {
+	tx, err := helper.BeginTx(ctx, repository.Database, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := "UPDATE comments SET pinned_at=NULL WHERE id=$1 AND deleted_at IS NULL"
+	_, err = tx.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetCommentById is a method to retrieve a comment that isn't deleted, on a photo that isn't deleted either.
+func (repository *CommentRepositoryImpl) GetCommentById(ctx context.Context, id int) (domain.Comment, error) {
+	tx, err := helper.BeginTx(ctx, repository.Database, helper.ReadOnly)
+	if err != nil {
+		return domain.Comment{}, err
+	}
+	defer tx.Rollback()
+
+	comment := domain.Comment{}
+	query := "SELECT comments.id, comments.user_id, comments.photo_id, COALESCE(comments.parent_id, 0), comments.depth, comments.message, comments.created_at, comments.updated_at " +
+		"FROM comments JOIN photos ON photos.id = comments.photo_id WHERE comments.id=$1 AND comments.deleted_at IS NULL AND photos.deleted_at IS NULL"
+	err = tx.QueryRowContext(ctx, query, id).Scan(&comment.Id, &comment.UserId, &comment.PhotoId, &comment.ParentId, &comment.Depth, &comment.Message, &comment.CreatedAt, &comment.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return domain.Comment{}, ErrCommentNotFound
+	}
+	if err != nil {
+		return domain.Comment{}, err
+	}
+
+	return comment, tx.Commit()
 }
 
 // UpdateComment is a method to update a comment entry in the database.
 func (repository *CommentRepositoryImpl) UpdateComment(ctx context.Context, comment domain.Comment) (domain.Comment, error) {
-	tx, err := repository.Database.Begin()
+	tx, err := helper.BeginTx(ctx, repository.Database, nil)
 	if err != nil {
-		return domain.Comment{}, nil
+		return domain.Comment{}, err
 	}
-	defer helper.CommitOrRollback(tx, &err)
+	defer tx.Rollback()
 
-	comment.UpdatedAt = int32(time.Now().Unix())
+	query := "UPDATE comments SET message=$1, updated_at=now() WHERE id=$2 AND deleted_at IS NULL RETURNING photo_id, user_id, created_at, updated_at"
+	row := tx.QueryRowContext(ctx, query, comment.Message, comment.Id)
 
-	query := "UPDATE comments SET message=$1, updated_at=$2, user_id=$3 WHERE id=$4 RETURNING photo_id"
-	row := tx.QueryRowContext(ctx, query, comment.Message, comment.UpdatedAt, comment.UserId, comment.Id)
-
-	err = row.Scan(&comment.PhotoId)
+	err = row.Scan(&comment.PhotoId, &comment.UserId, &comment.CreatedAt, &comment.UpdatedAt)
+	if err != nil {
+		return domain.Comment{}, err
+	}
+	comment.Entities, err = saveEntities(ctx, tx, commentEntityTables, comment.Id, comment.Entities)
 	if err != nil {
 		return domain.Comment{}, err
 	}
 
-	return comment, nil
+	return comment, tx.Commit()
 }
 
-// DeleteComment is a method to "soft delete" a comment entry by setting the deleted_at field in the database.
+// DeleteComment is a method to "soft delete" a comment entry, and the replies under it, by setting the deleted_at field in the database.
 func (repository *CommentRepositoryImpl) DeleteComment(ctx context.Context, id int) error {
-	tx, err := repository.Database.Begin()
+	tx, err := helper.BeginTx(ctx, repository.Database, nil)
 	if err != nil {
-		return nil
+		return err
 	}
-	defer helper.CommitOrRollback(tx, &err)
-
-	deleteTime := int32(time.Now().Unix())
+	defer tx.Rollback()
 
-	query := "UPDATE comments SET deleted_at = $1 WHERE id = $2"
-	result, err := tx.ExecContext(ctx, query, deleteTime, id)
+	query := "WITH RECURSIVE thread AS (SELECT id FROM comments WHERE id = $1 AND deleted_at IS NULL " +
+		"UNION SELECT comments.id FROM comments JOIN thread ON comments.parent_id = thread.id WHERE comments.deleted_at IS NULL) " +
+		"UPDATE comments SET deleted_at = now() WHERE id IN (SELECT id FROM thread)"
+	result, err := tx.ExecContext(ctx, query, id)
 	if err != nil {
 		return err
 	}
@@ -118,8 +386,31 @@ func (repository *CommentRepositoryImpl) DeleteComment(ctx context.Context, id i
 		return err
 	}
 	if rows == 0 {
-		return errors.New("comment not found")
+		return ErrCommentNotFound
+	}
+
+	return tx.Commit()
+}
+
+// GetCommentOwner is a method to retrieve the id of the user who owns a comment. The row stays locked until the
+// transaction it runs in ends, so a caller that checks the owner within a unit of work can act on the row knowing it
+// hasn't changed since.
+func (repository *CommentRepositoryImpl) GetCommentOwner(ctx context.Context, id int) (uuid.UUID, error) {
+	tx, err := helper.BeginTx(ctx, repository.Database, nil)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	defer tx.Rollback()
+
+	var userId uuid.UUID
+	query := "SELECT user_id FROM comments WHERE id=$1 AND deleted_at IS NULL FOR UPDATE"
+	err = tx.QueryRowContext(ctx, query, id).Scan(&userId)
+	if err == sql.ErrNoRows {
+		return uuid.Nil, ErrCommentNotFound
+	}
+	if err != nil {
+		return uuid.Nil, err
 	}
 
-	return nil
+	return userId, tx.Commit()
 }
@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/dihanto/gosnap/internal/app/helper"
+	"github.com/dihanto/gosnap/model/domain"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// entityTables names the link tables holding the entities of photos or of comments
+// and the column they reference their photo or comment by.
+type entityTables struct {
+	owner    string
+	hashtags string
+	mentions string
+}
+
+var (
+	photoEntityTables   = entityTables{owner: "photo_id", hashtags: "photo_hashtags", mentions: "photo_mentions"}
+	commentEntityTables = entityTables{owner: "comment_id", hashtags: "comment_hashtags", mentions: "comment_mentions"}
+)
+
+// saveEntities replaces the entities of the photo or comment id and returns the ones stored.
+// Mentions of users that don't exist, are deleted or are suspended are left out.
+func saveEntities(ctx context.Context, tx *helper.Tx, tables entityTables, id int, entities []domain.Entity) ([]domain.Entity, error) {
+	for _, table := range []string{tables.hashtags, tables.mentions} {
+		_, err := tx.ExecContext(ctx, "DELETE FROM "+table+" WHERE "+tables.owner+"=$1", id)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	saved := []domain.Entity{}
+	for _, entity := range entities {
+		switch entity.Type {
+		case domain.EntityMention:
+			query := "INSERT INTO " + tables.mentions + " (" + tables.owner + ", user_id, start_offset, end_offset) " +
+				"SELECT $1, id, $3, $4 FROM users WHERE username=$2 AND deleted_at IS NULL AND suspended_at IS NULL RETURNING user_id"
+			err := tx.QueryRowContext(ctx, query, id, entity.Username, entity.Start, entity.End).Scan(&entity.UserId)
+			if err == sql.ErrNoRows {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+		case domain.EntityHashtag:
+			query := "WITH hashtag AS (INSERT INTO hashtags (name) VALUES ($2) ON CONFLICT (name) DO UPDATE SET name=EXCLUDED.name RETURNING id) " +
+				"INSERT INTO " + tables.hashtags + " (" + tables.owner + ", hashtag_id, start_offset, end_offset) SELECT $1, id, $3, $4 FROM hashtag"
+			_, err := tx.ExecContext(ctx, query, id, entity.Tag, entity.Start, entity.End)
+			if err != nil {
+				return nil, err
+			}
+		default:
+			continue
+		}
+		saved = append(saved, entity)
+	}
+
+	return saved, nil
+}
+
+// loadEntities returns the entities of the photos or comments ids, in text order, keyed by id.
+// Mentions name the user's current username.
+func loadEntities(ctx context.Context, tx *helper.Tx, tables entityTables, ids []int) (map[int][]domain.Entity, error) {
+	entities := map[int][]domain.Entity{}
+	if len(ids) == 0 {
+		return entities, nil
+	}
+
+	query := "SELECT mentions." + tables.owner + ", 'mention', mentions.start_offset, mentions.end_offset, '', users.username, users.id " +
+		"FROM " + tables.mentions + " mentions JOIN users ON users.id = mentions.user_id " +
+		"WHERE mentions." + tables.owner + " = ANY($1) AND users.deleted_at IS NULL AND users.suspended_at IS NULL " +
+		"UNION ALL SELECT hashtag_links." + tables.owner + ", 'hashtag', hashtag_links.start_offset, hashtag_links.end_offset, hashtags.name, '', NULL " +
+		"FROM " + tables.hashtags + " hashtag_links JOIN hashtags ON hashtags.id = hashtag_links.hashtag_id " +
+		"WHERE hashtag_links." + tables.owner + " = ANY($1) ORDER BY 1, 3"
+	rows, err := tx.QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		var entity domain.Entity
+		var userId uuid.NullUUID
+		err = rows.Scan(&id, &entity.Type, &entity.Start, &entity.End, &entity.Tag, &entity.Username, &userId)
+		if err != nil {
+			return nil, err
+		}
+		entity.UserId = userId.UUID
+		entities[id] = append(entities[id], entity)
+	}
+
+	return entities, rows.Err()
+}
+
+// attachPhotoEntities loads the entities of photos into them.
+func attachPhotoEntities(ctx context.Context, tx *helper.Tx, photos []domain.Photo) error {
+	ids := make([]int, 0, len(photos))
+	for _, photo := range photos {
+		ids = append(ids, photo.Id)
+	}
+	entities, err := loadEntities(ctx, tx, photoEntityTables, ids)
+	if err != nil {
+		return err
+	}
+	for i := range photos {
+		photos[i].Entities = entities[photos[i].Id]
+	}
+
+	return nil
+}
+
+// attachCommentEntities loads the entities of comments into them.
+func attachCommentEntities(ctx context.Context, tx *helper.Tx, comments []domain.Comment) error {
+	ids := make([]int, 0, len(comments))
+	for _, comment := range comments {
+		ids = append(ids, comment.Id)
+	}
+	entities, err := loadEntities(ctx, tx, commentEntityTables, ids)
+	if err != nil {
+		return err
+	}
+	for i := range comments {
+		comments[i].Entities = entities[comments[i].Id]
+	}
+
+	return nil
+}
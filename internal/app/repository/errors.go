@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/dihanto/gosnap/internal/app/exception"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrUserNotFound        = exception.NotFound("user_not_found", "User not found")
+	ErrPhotoNotFound       = exception.NotFound("photo_not_found", "Photo not found")
+	ErrCommentNotFound     = exception.NotFound("comment_not_found", "Comment not found")
+	ErrSocialMediaNotFound = exception.NotFound("social_media_not_found", "Social media not found")
+	ErrPasswordMismatch    = exception.Validation("password_mismatch", "Password does not match")
+	ErrEmailTaken          = exception.Conflict("email_taken", "Email is already taken")
+	ErrUsernameTaken       = exception.Conflict("username_taken", "Username is already taken")
+	ErrUsernameReserved    = exception.Conflict("username_reserved", "Username was recently used by another account")
+	ErrAlreadyLiked        = exception.Conflict("already_liked", "You have already liked this photo")
+	ErrAlreadyFollowing    = exception.Conflict("already_following", "You are already following this user")
+	ErrFollowSelf          = exception.Validation("follow_self", "You cannot follow yourself")
+	ErrModerateSelf        = exception.Validation("moderate_self", "You cannot suspend yourself or change your own role")
+	ErrReplyTooDeep        = exception.Validation("reply_too_deep", "Replies cannot be nested any deeper")
+	ErrAlreadyLikedComment = exception.Conflict("already_liked_comment", "You have already liked this comment")
+	ErrPinReply            = exception.Validation("pin_reply", "Only comments on the photo itself can be pinned")
+	ErrPinLimitReached     = exception.Conflict("pin_limit_reached", "This photo already has as many pinned comments as allowed")
+)
+
+// uniqueViolation reports whether err is postgres rejecting a row because it breaks
+// the unique constraint named constraint.
+func uniqueViolation(err error, constraint string) bool {
+	var pqError *pq.Error
+	return errors.As(err, &pqError) && pqError.Code == "23505" && pqError.Constraint == constraint
+}
+
+// checkViolation reports whether err is postgres rejecting a row because it fails
+// the check constraint named constraint.
+func checkViolation(err error, constraint string) bool {
+	var pqError *pq.Error
+	return errors.As(err, &pqError) && pqError.Code == "23514" && pqError.Constraint == constraint
+}
+
+// userConflict turns a unique violation on users into ErrEmailTaken or ErrUsernameTaken.
+func userConflict(err error) error {
+	switch {
+	case uniqueViolation(err, "email_uniq"):
+		return ErrEmailTaken
+	case uniqueViolation(err, "username_uniq"):
+		return ErrUsernameTaken
+	default:
+		return err
+	}
+}
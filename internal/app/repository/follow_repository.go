@@ -4,11 +4,12 @@ import (
 	"context"
 
 	"github.com/dihanto/gosnap/model/domain"
+	"github.com/google/uuid"
 )
 
 type FollowRepository interface {
 	FollowUser(ctx context.Context, follow domain.Follow) (domain.Follow, error)
 	UnFollowUser(ctx context.Context, follow domain.Follow) (domain.Follow, error)
-	GetFollower(ctx context.Context, request domain.Follow) (followers []domain.User, err error)
-	GetFollowing(ctx context.Context, request domain.Follow) (follows []domain.User, err error)
+	GetFollower(ctx context.Context, userId uuid.UUID, page domain.Page) (followers []domain.User, count int, next domain.Cursor, err error)
+	GetFollowing(ctx context.Context, userId uuid.UUID, page domain.Page) (follows []domain.User, count int, next domain.Cursor, err error)
 }
@@ -3,10 +3,11 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"strconv"
 
 	"github.com/dihanto/gosnap/internal/app/helper"
 	"github.com/dihanto/gosnap/model/domain"
-	"github.com/lib/pq"
+	"github.com/google/uuid"
 )
 
 type FollowRepositoryImpl struct {
@@ -19,137 +20,156 @@ func NewFollowRepositoryImpl(database *sql.DB) FollowRepository {
 	}
 }
 
+// FollowUser is a method to make a user follow the user named by follow.TargetUsername.
 func (repository *FollowRepositoryImpl) FollowUser(ctx context.Context, follow domain.Follow) (domain.Follow, error) {
-	tx, err := repository.Database.Begin()
+	tx, err := helper.BeginTx(ctx, repository.Database, nil)
 	if err != nil {
 		return domain.Follow{}, err
 	}
-	defer helper.CommitOrRollback(tx, &err)
+	defer tx.Rollback()
 
-	query := "UPDATE followers SET follower_count=follower_count+1 WHERE username=$1 RETURNING id"
-	err = tx.QueryRowContext(ctx, query, follow.TargetUsername).Scan(&follow.Id)
-	if err != nil {
-		return domain.Follow{}, err
+	query := "INSERT INTO follows (follower_id, followee_id) SELECT $1, id FROM users WHERE username=$2 AND deleted_at IS NULL RETURNING followee_id, created_at"
+	err = tx.QueryRowContext(ctx, query, follow.FollowerId, follow.TargetUsername).Scan(&follow.FolloweeId, &follow.CreatedAt)
+	switch {
+	case err == sql.ErrNoRows:
+		err = ErrUserNotFound
+	case uniqueViolation(err, "follows_pkey"):
+		err = ErrAlreadyFollowing
+	case checkViolation(err, "follows_not_self"):
+		err = ErrFollowSelf
 	}
-
-	queryFollow := "INSERT INTO follower_details(follow_id, follower_name) VALUES ($1, $2)"
-	_, err = tx.ExecContext(ctx, queryFollow, follow.Id, follow.FollowerUsername)
 	if err != nil {
 		return domain.Follow{}, err
 	}
 
-	queryResult := "SELECT follower_count FROM followers WHERE username=$1"
-	err = tx.QueryRowContext(ctx, queryResult, follow.TargetUsername).Scan(&follow.FollowerCount)
+	queryResult := "SELECT follower_count FROM users WHERE id=$1"
+	err = tx.QueryRowContext(ctx, queryResult, follow.FolloweeId).Scan(&follow.FollowerCount)
 	if err != nil {
 		return domain.Follow{}, err
 	}
 
-	return follow, nil
+	return follow, tx.Commit()
 }
 
+// UnFollowUser is a method to make a user stop following the user named by follow.TargetUsername.
 func (repository *FollowRepositoryImpl) UnFollowUser(ctx context.Context, follow domain.Follow) (domain.Follow, error) {
-	tx, err := repository.Database.Begin()
+	tx, err := helper.BeginTx(ctx, repository.Database, nil)
 	if err != nil {
 		return domain.Follow{}, err
 	}
-	defer helper.CommitOrRollback(tx, &err)
+	defer tx.Rollback()
 
-	query := "DELETE FROM follower_details WHERE follower_name=$1"
-	_, err = tx.ExecContext(ctx, query, follow.FollowerUsername)
+	queryUser := "SELECT id FROM users WHERE username=$1 AND deleted_at IS NULL"
+	err = tx.QueryRowContext(ctx, queryUser, follow.TargetUsername).Scan(&follow.FolloweeId)
+	if err == sql.ErrNoRows {
+		err = ErrUserNotFound
+	}
 	if err != nil {
 		return domain.Follow{}, err
 	}
 
-	queryUser := "UPDATE followers SET follower_count=follower_count-1 WHERE username=$1"
-	_, err = tx.ExecContext(ctx, queryUser, follow.TargetUsername)
+	query := "DELETE FROM follows WHERE follower_id=$1 AND followee_id=$2"
+	_, err = tx.ExecContext(ctx, query, follow.FollowerId, follow.FolloweeId)
 	if err != nil {
 		return domain.Follow{}, err
 	}
 
-	queryResult := "SELECT follower_count FROM followers WHERE username=$1"
-	err = tx.QueryRowContext(ctx, queryResult, follow.TargetUsername).Scan(&follow.FollowerCount)
+	queryResult := "SELECT follower_count FROM users WHERE id=$1"
+	err = tx.QueryRowContext(ctx, queryResult, follow.FolloweeId).Scan(&follow.FollowerCount)
 	if err != nil {
 		return domain.Follow{}, err
 	}
 
-	return follow, nil
-
+	return follow, tx.Commit()
 }
 
-func (repository *FollowRepositoryImpl) GetFollower(ctx context.Context, request domain.Follow) (followers []domain.User, err error) {
-	tx, err := repository.Database.Begin()
+// GetFollower is a method to retrieve a page of the users following a user, ordered by username.
+func (repository *FollowRepositoryImpl) GetFollower(ctx context.Context, userId uuid.UUID, page domain.Page) (followers []domain.User, count int, next domain.Cursor, err error) {
+	tx, err := helper.BeginTx(ctx, repository.Database, helper.ReadOnly)
 	if err != nil {
 		return
 	}
-	defer helper.CommitOrRollback(tx, &err)
+	defer tx.Rollback()
 
-	var followerId int
-	queryGetId := "SELECT id FROM followers WHERE username=$1"
-	err = tx.QueryRowContext(ctx, queryGetId, request.TargetUsername).Scan(&followerId)
+	queryCount := "SELECT follower_count FROM users WHERE id=$1"
+	err = tx.QueryRowContext(ctx, queryCount, userId).Scan(&count)
+	if err == sql.ErrNoRows {
+		err = ErrUserNotFound
+	}
 	if err != nil {
 		return
 	}
 
-	queryGetFollower := "SELECT follower_name FROM follower_details WHERE follow_id=$1"
-	rows, err := tx.QueryContext(ctx, queryGetFollower, followerId)
+	query := "SELECT users.id, users.username FROM follows JOIN users ON users.id = follows.follower_id WHERE follows.followee_id=$1 AND users.deleted_at IS NULL"
+	followers, next, err = repository.getUserPage(ctx, tx, query, userId, page)
 	if err != nil {
 		return
 	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var follower domain.User
-		err = rows.Scan(&follower.Username)
-		if err != nil {
-			return
-		}
 
-		followers = append(followers, follower)
-	}
+	err = tx.Commit()
 	return
 }
 
-func (repository *FollowRepositoryImpl) GetFollowing(ctx context.Context, request domain.Follow) (follows []domain.User, err error) {
-	tx, err := repository.Database.Begin()
+// GetFollowing is a method to retrieve a page of the users a user follows, ordered by username.
+func (repository *FollowRepositoryImpl) GetFollowing(ctx context.Context, userId uuid.UUID, page domain.Page) (follows []domain.User, count int, next domain.Cursor, err error) {
+	tx, err := helper.BeginTx(ctx, repository.Database, helper.ReadOnly)
 	if err != nil {
 		return
 	}
-	defer helper.CommitOrRollback(tx, &err)
+	defer tx.Rollback()
 
-	queryFollowId := "SELECT follow_id FROM follower_details WHERE follower_name=$1"
-	rows, err := tx.QueryContext(ctx, queryFollowId, request.TargetUsername)
+	queryCount := "SELECT following_count FROM users WHERE id=$1"
+	err = tx.QueryRowContext(ctx, queryCount, userId).Scan(&count)
+	if err == sql.ErrNoRows {
+		err = ErrUserNotFound
+	}
 	if err != nil {
 		return
 	}
-	defer rows.Close()
 
-	var followIds []int
-	for rows.Next() {
-		var followId int
-		err = rows.Scan(&followId)
-		if err != nil {
-			return
-		}
-		followIds = append(followIds, followId)
+	query := "SELECT users.id, users.username FROM follows JOIN users ON users.id = follows.followee_id WHERE follows.follower_id=$1 AND users.deleted_at IS NULL"
+	follows, next, err = repository.getUserPage(ctx, tx, query, userId, page)
+	if err != nil {
+		return
+	}
+
+	err = tx.Commit()
+	return
+}
+
+// getUserPage pages through the users selected by query, whose only placeholder is bound to userId,
+// ordered by username.
+func (repository *FollowRepositoryImpl) getUserPage(ctx context.Context, tx *helper.Tx, query string, userId uuid.UUID, page domain.Page) (users []domain.User, next domain.Cursor, err error) {
+	params := []interface{}{userId}
+	if !page.Cursor.IsZero() {
+		query += " AND users.username > $2"
+		params = append(params, page.Cursor.Key)
 	}
+	query += " ORDER BY users.username LIMIT $" + strconv.Itoa(len(params)+1)
+	params = append(params, page.Limit+1)
 
-	// for _, id := range followIds {
-	queryFollows := "SELECT username FROM followers WHERE id= ANY ($1)"
-	rowUsers, err := tx.QueryContext(ctx, queryFollows, pq.Array(followIds))
+	rows, err := tx.QueryContext(ctx, query, params...)
 	if err != nil {
 		return
 	}
+	defer rows.Close()
 
-	for rowUsers.Next() {
-		var follow domain.User
-		err = rowUsers.Scan(&follow.Username)
+	for rows.Next() {
+		var user domain.User
+		err = rows.Scan(&user.Id, &user.Username)
 		if err != nil {
 			return
 		}
-		follows = append(follows, follow)
+		users = append(users, user)
+	}
+	err = rows.Err()
+	if err != nil {
+		return
 	}
-	// follows = append(follows, follow)
-	// }
 
+	if len(users) > page.Limit {
+		users = users[:page.Limit]
+		next = domain.Cursor{Key: users[len(users)-1].Username}
+	}
 	return
 }
@@ -10,5 +10,7 @@ import (
 type LikeRepository interface {
 	LikePhoto(ctx context.Context, like domain.Like) (domain.Like, error)
 	UnlikePhoto(ctx context.Context, like domain.Like) (domain.Like, error)
-	IsLikePhoto(ctx context.Context, photoId int) ([]uuid.UUID, error)
+	IsLikePhoto(ctx context.Context, photoId int, userId uuid.UUID) (bool, error)
+	LikeComment(ctx context.Context, like domain.CommentLike) (domain.CommentLike, error)
+	UnlikeComment(ctx context.Context, like domain.CommentLike) (domain.CommentLike, error)
 }
@@ -18,119 +18,137 @@ func NewLikeRepository(database *sql.DB) LikeRepository {
 		Database: database,
 	}
 }
+
+// LikePhoto is a method to record that a user likes a photo.
 func (repository *LikeRepositoryImpl) LikePhoto(ctx context.Context, like domain.Like) (domain.Like, error) {
-	tx, err := repository.Database.Begin()
+	tx, err := helper.BeginTx(ctx, repository.Database, nil)
 	if err != nil {
 		return domain.Like{}, err
 	}
-	defer helper.CommitOrRollback(tx, &err)
+	defer tx.Rollback()
 
-	query := "UPDATE likes SET like_count=like_count+1 WHERE photo_id=$1 RETURNING id"
-	err = tx.QueryRowContext(ctx, query, like.PhotoId).Scan(&like.Id)
+	query := "INSERT INTO photo_likes (photo_id, user_id) SELECT id, $2 FROM photos WHERE id=$1 AND deleted_at IS NULL RETURNING created_at"
+	err = tx.QueryRowContext(ctx, query, like.PhotoId, like.UserId).Scan(&like.CreatedAt)
+	switch {
+	case err == sql.ErrNoRows:
+		err = ErrPhotoNotFound
+	case uniqueViolation(err, "photo_likes_pkey"):
+		err = ErrAlreadyLiked
+	}
 	if err != nil {
 		return domain.Like{}, err
 	}
 
-	queryLikeDetail := "INSERT INTO like_details (like_id, user_id, liked_at) VALUES ($1, $2, $3)"
-	_, err = tx.ExecContext(ctx, queryLikeDetail, like.Id, like.UserId, like.CreatedAt)
+	queryResult := "SELECT like_count FROM photos WHERE id=$1"
+	err = tx.QueryRowContext(ctx, queryResult, like.PhotoId).Scan(&like.LikeCount)
 	if err != nil {
 		return domain.Like{}, err
 	}
 
-	queryGetLike := "SELECT like_count FROM likes WHERE photo_id=$1"
-	row, err := tx.QueryContext(ctx, queryGetLike, like.PhotoId)
+	return like, tx.Commit()
+}
+
+// UnlikePhoto is a method to remove a user's like from a photo.
+func (repository *LikeRepositoryImpl) UnlikePhoto(ctx context.Context, like domain.Like) (domain.Like, error) {
+	tx, err := helper.BeginTx(ctx, repository.Database, nil)
 	if err != nil {
 		return domain.Like{}, err
 	}
-	defer row.Close()
+	defer tx.Rollback()
 
-	if row.Next() {
-		err = row.Scan(&like.LikeCount)
-		if err != nil {
-			return domain.Like{}, err
-		}
+	query := "DELETE FROM photo_likes WHERE photo_id=$1 AND user_id=$2"
+	_, err = tx.ExecContext(ctx, query, like.PhotoId, like.UserId)
+	if err != nil {
+		return domain.Like{}, err
 	}
 
-	likeResponse := domain.Like{
-		PhotoId:   like.PhotoId,
-		UserId:    like.UserId,
-		LikeCount: like.LikeCount,
-		CreatedAt: like.CreatedAt,
+	queryResult := "SELECT like_count FROM photos WHERE id=$1 AND deleted_at IS NULL"
+	err = tx.QueryRowContext(ctx, queryResult, like.PhotoId).Scan(&like.LikeCount)
+	if err == sql.ErrNoRows {
+		err = ErrPhotoNotFound
 	}
-	return likeResponse, nil
-
-}
-
-func (repository *LikeRepositoryImpl) UnlikePhoto(ctx context.Context, like domain.Like) (domain.Like, error) {
-	tx, err := repository.Database.Begin()
 	if err != nil {
 		return domain.Like{}, err
 	}
-	defer helper.CommitOrRollback(tx, &err)
 
-	query := "UPDATE likes SET like_count=like_count-1 WHERE photo_id=$1"
-	_, err = tx.ExecContext(ctx, query, like.PhotoId)
+	return domain.Like{
+		PhotoId:   like.PhotoId,
+		LikeCount: like.LikeCount,
+	}, tx.Commit()
+}
+
+// IsLikePhoto is a method to check whether a user likes a photo.
+func (repository *LikeRepositoryImpl) IsLikePhoto(ctx context.Context, photoId int, userId uuid.UUID) (bool, error) {
+	tx, err := helper.BeginTx(ctx, repository.Database, helper.ReadOnly)
 	if err != nil {
-		return domain.Like{}, err
+		return false, err
 	}
+	defer tx.Rollback()
 
-	queryLikeDetail := "DELETE FROM like_details WHERE user_id=$1"
-	_, err = tx.ExecContext(ctx, queryLikeDetail, like.UserId)
+	var liked bool
+	query := "SELECT EXISTS (SELECT 1 FROM photo_likes WHERE photo_id=$1 AND user_id=$2)"
+	err = tx.QueryRowContext(ctx, query, photoId, userId).Scan(&liked)
 	if err != nil {
-		return domain.Like{}, err
+		return false, err
 	}
 
-	queryResult := "SELECT like_count FROM likes WHERE photo_id=$1"
-	row, err := tx.QueryContext(ctx, queryResult, like.PhotoId)
+	return liked, tx.Commit()
+}
+
+// LikeComment is a method to record that a user likes a comment.
+func (repository *LikeRepositoryImpl) LikeComment(ctx context.Context, like domain.CommentLike) (domain.CommentLike, error) {
+	tx, err := helper.BeginTx(ctx, repository.Database, nil)
 	if err != nil {
-		return domain.Like{}, err
+		return domain.CommentLike{}, err
 	}
-	defer row.Close()
+	defer tx.Rollback()
 
-	if row.Next() {
-		err = row.Scan(&like.LikeCount)
-		if err != nil {
-			return domain.Like{}, err
-		}
+	query := "INSERT INTO comment_likes (comment_id, user_id) SELECT id, $2 FROM comments WHERE id=$1 AND deleted_at IS NULL RETURNING created_at"
+	err = tx.QueryRowContext(ctx, query, like.CommentId, like.UserId).Scan(&like.CreatedAt)
+	switch {
+	case err == sql.ErrNoRows:
+		err = ErrCommentNotFound
+	case uniqueViolation(err, "comment_likes_pkey"):
+		err = ErrAlreadyLikedComment
+	}
+	if err != nil {
+		return domain.CommentLike{}, err
 	}
 
-	likeResponse := domain.Like{
-		PhotoId:   like.PhotoId,
-		LikeCount: like.LikeCount,
+	queryResult := "SELECT like_count FROM comments WHERE id=$1"
+	err = tx.QueryRowContext(ctx, queryResult, like.CommentId).Scan(&like.LikeCount)
+	if err != nil {
+		return domain.CommentLike{}, err
 	}
 
-	return likeResponse, nil
+	return like, tx.Commit()
 }
 
-func (repository *LikeRepositoryImpl) IsLikePhoto(ctx context.Context, photoId int) ([]uuid.UUID, error) {
-	tx, err := repository.Database.Begin()
+// UnlikeComment is a method to remove a user's like from a comment.
+func (repository *LikeRepositoryImpl) UnlikeComment(ctx context.Context, like domain.CommentLike) (domain.CommentLike, error) {
+	tx, err := helper.BeginTx(ctx, repository.Database, nil)
 	if err != nil {
-		return nil, err
+		return domain.CommentLike{}, err
 	}
-	defer helper.CommitOrRollback(tx, &err)
+	defer tx.Rollback()
 
-	var likeId int
-	queryGetLikeId := "SELECT id FROM likes WHERE photo_id=$1"
-	err = tx.QueryRowContext(ctx, queryGetLikeId, photoId).Scan(&likeId)
+	query := "DELETE FROM comment_likes WHERE comment_id=$1 AND user_id=$2"
+	_, err = tx.ExecContext(ctx, query, like.CommentId, like.UserId)
 	if err != nil {
-		return nil, err
+		return domain.CommentLike{}, err
 	}
 
-	query := "SELECT user_id FROM like_details WHERE like_id=$1"
-	rows, err := tx.QueryContext(ctx, query, likeId)
+	queryResult := "SELECT like_count FROM comments WHERE id=$1 AND deleted_at IS NULL"
+	err = tx.QueryRowContext(ctx, queryResult, like.CommentId).Scan(&like.LikeCount)
+	if err == sql.ErrNoRows {
+		err = ErrCommentNotFound
+	}
 	if err != nil {
-		return nil, err
+		return domain.CommentLike{}, err
 	}
-	defer rows.Close()
 
-	var userIds []uuid.UUID
-	for rows.Next() {
-		var userId uuid.UUID
-		err = rows.Scan(&userId)
-		if err != nil {
-			return nil, err
-		}
-		userIds = append(userIds, userId)
-	}
-	return userIds, nil
+	return domain.CommentLike{
+		CommentId: like.CommentId,
+		LikeCount: like.LikeCount,
+	}, tx.Commit()
 }
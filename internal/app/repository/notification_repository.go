@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/dihanto/gosnap/model/domain"
+	"github.com/google/uuid"
+)
+
+type NotificationRepository interface {
+	Record(ctx context.Context, notification domain.Notification) error
+	Retract(ctx context.Context, notification domain.Notification) error
+	GetNotifications(ctx context.Context, userId uuid.UUID, page domain.Page) ([]domain.Notification, []domain.User, domain.Cursor, error)
+	CountUnread(ctx context.Context, userId uuid.UUID) (int, error)
+	MarkRead(ctx context.Context, userId uuid.UUID, ids []int) error
+	GetPreferences(ctx context.Context, userId uuid.UUID) (domain.NotificationPreferences, error)
+	UpdatePreferences(ctx context.Context, preferences domain.NotificationPreferences) (domain.NotificationPreferences, error)
+}
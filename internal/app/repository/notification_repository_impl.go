@@ -0,0 +1,236 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+
+	"github.com/dihanto/gosnap/internal/app/helper"
+	"github.com/dihanto/gosnap/model/domain"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// preferenceColumns are the notification_preferences columns that turn each type of notification on or off.
+var preferenceColumns = map[string]string{
+	domain.NotificationLike:    "likes",
+	domain.NotificationComment: "comments",
+	domain.NotificationFollow:  "follows",
+	domain.NotificationMention: "mentions",
+}
+
+type NotificationRepositoryImpl struct {
+	Database *sql.DB
+}
+
+func NewNotificationRepository(database *sql.DB) NotificationRepository {
+	return &NotificationRepositoryImpl{
+		Database: database,
+	}
+}
+
+// Record is a method to add the actor of notification to the recipient's unread notification of the same type
+// about the same photo or comment, or to a new one when there is none. Nothing is recorded when the recipient
+// turned that type of notification off.
+func (repository *NotificationRepositoryImpl) Record(ctx context.Context, notification domain.Notification) error {
+	tx, err := helper.BeginTx(ctx, repository.Database, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := "INSERT INTO notifications (user_id, type, photo_id, comment_id) SELECT $1, $2, NULLIF($3, 0), NULLIF($4, 0) " +
+		"WHERE COALESCE((SELECT " + preferenceColumns[notification.Type] + " FROM notification_preferences WHERE user_id = $1), true) " +
+		"ON CONFLICT (user_id, type, COALESCE(photo_id, 0), COALESCE(comment_id, 0)) WHERE read_at IS NULL DO UPDATE SET updated_at=now() RETURNING id"
+	err = tx.QueryRowContext(ctx, query, notification.UserId, notification.Type, notification.PhotoId, notification.CommentId).Scan(&notification.Id)
+	if err == sql.ErrNoRows {
+		return tx.Commit()
+	}
+	if err != nil {
+		return err
+	}
+
+	queryActor := "INSERT INTO notification_actors (notification_id, actor_id) VALUES ($1, $2) ON CONFLICT (notification_id, actor_id) DO UPDATE SET created_at=now()"
+	_, err = tx.ExecContext(ctx, queryActor, notification.Id, notification.ActorId)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Retract is a method to take the actor of notification back out of the recipient's unread notification of the same
+// type about the same photo or comment, and to drop that notification once it has no actors left.
+func (repository *NotificationRepositoryImpl) Retract(ctx context.Context, notification domain.Notification) error {
+	tx, err := helper.BeginTx(ctx, repository.Database, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := "DELETE FROM notification_actors USING notifications WHERE notification_actors.notification_id = notifications.id " +
+		"AND notifications.user_id = $1 AND notifications.type = $2 AND COALESCE(notifications.photo_id, 0) = $3 AND COALESCE(notifications.comment_id, 0) = $4 " +
+		"AND notifications.read_at IS NULL AND notification_actors.actor_id = $5 RETURNING notifications.id"
+	err = tx.QueryRowContext(ctx, query, notification.UserId, notification.Type, notification.PhotoId, notification.CommentId, notification.ActorId).Scan(&notification.Id)
+	if err == sql.ErrNoRows {
+		return tx.Commit()
+	}
+	if err != nil {
+		return err
+	}
+
+	queryEmpty := "DELETE FROM notifications WHERE id=$1 AND NOT EXISTS (SELECT 1 FROM notification_actors WHERE notification_id=$1)"
+	_, err = tx.ExecContext(ctx, queryEmpty, notification.Id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetNotifications is a method to retrieve a page of a user's notifications, most recently updated first,
+// with their latest actors. Notifications about deleted photos or comments are left out.
+func (repository *NotificationRepositoryImpl) GetNotifications(ctx context.Context, userId uuid.UUID, page domain.Page) ([]domain.Notification, []domain.User, domain.Cursor, error) {
+	tx, err := helper.BeginTx(ctx, repository.Database, helper.ReadOnly)
+	if err != nil {
+		return []domain.Notification{}, []domain.User{}, domain.Cursor{}, err
+	}
+	defer tx.Rollback()
+
+	query := "SELECT notifications.id, notifications.type, COALESCE(notifications.photo_id, 0), COALESCE(notifications.comment_id, 0), notifications.read_at, notifications.created_at, notifications.updated_at, " +
+		"(SELECT COUNT(*) FROM notification_actors JOIN users ON users.id = notification_actors.actor_id WHERE notification_actors.notification_id = notifications.id AND users.deleted_at IS NULL), " +
+		"actors.id, actors.username, actors.profile_picture_base64 FROM notifications " +
+		"JOIN LATERAL (SELECT users.id, users.username, users.profile_picture_base64 FROM notification_actors JOIN users ON users.id = notification_actors.actor_id " +
+		"WHERE notification_actors.notification_id = notifications.id AND users.deleted_at IS NULL ORDER BY notification_actors.created_at DESC LIMIT 1) actors ON true " +
+		"LEFT JOIN photos ON photos.id = notifications.photo_id LEFT JOIN comments ON comments.id = notifications.comment_id " +
+		"WHERE notifications.user_id = $1 AND photos.deleted_at IS NULL AND comments.deleted_at IS NULL"
+	params := []interface{}{userId}
+	if !page.Cursor.IsZero() {
+		cursorId, errCursor := strconv.Atoi(page.Cursor.Key)
+		if errCursor != nil {
+			return []domain.Notification{}, []domain.User{}, domain.Cursor{}, helper.ErrInvalidCursor
+		}
+		query += " AND (notifications.updated_at, notifications.id) < ($2, $3)"
+		params = append(params, page.Cursor.Timestamp(), cursorId)
+	}
+	query += " ORDER BY notifications.updated_at DESC, notifications.id DESC LIMIT $" + strconv.Itoa(len(params)+1)
+	params = append(params, page.Limit+1)
+
+	rows, err := tx.QueryContext(ctx, query, params...)
+	if err != nil {
+		return []domain.Notification{}, []domain.User{}, domain.Cursor{}, err
+	}
+	defer rows.Close()
+
+	notifications := []domain.Notification{}
+	actors := []domain.User{}
+	for rows.Next() {
+		notification := domain.Notification{UserId: userId}
+		var actor domain.User
+		err = rows.Scan(&notification.Id, &notification.Type, &notification.PhotoId, &notification.CommentId, &notification.ReadAt, &notification.CreatedAt, &notification.UpdatedAt,
+			&notification.ActorCount, &actor.Id, &actor.Username, &actor.ProfilePicture)
+		if err != nil {
+			return []domain.Notification{}, []domain.User{}, domain.Cursor{}, err
+		}
+		notification.ActorId = actor.Id
+		notifications = append(notifications, notification)
+		actors = append(actors, actor)
+	}
+	err = rows.Err()
+	if err != nil {
+		return []domain.Notification{}, []domain.User{}, domain.Cursor{}, err
+	}
+
+	next := domain.Cursor{}
+	if len(notifications) > page.Limit {
+		notifications, actors = notifications[:page.Limit], actors[:page.Limit]
+		last := notifications[len(notifications)-1]
+		next = domain.NewTimeCursor(last.UpdatedAt, strconv.Itoa(last.Id))
+	}
+
+	return notifications, actors, next, tx.Commit()
+}
+
+// CountUnread is a method to count the unread notifications GetNotifications lists.
+func (repository *NotificationRepositoryImpl) CountUnread(ctx context.Context, userId uuid.UUID) (int, error) {
+	tx, err := helper.BeginTx(ctx, repository.Database, helper.ReadOnly)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var count int
+	query := "SELECT COUNT(*) FROM notifications LEFT JOIN photos ON photos.id = notifications.photo_id LEFT JOIN comments ON comments.id = notifications.comment_id " +
+		"WHERE notifications.user_id = $1 AND notifications.read_at IS NULL AND photos.deleted_at IS NULL AND comments.deleted_at IS NULL " +
+		"AND EXISTS (SELECT 1 FROM notification_actors JOIN users ON users.id = notification_actors.actor_id WHERE notification_actors.notification_id = notifications.id AND users.deleted_at IS NULL)"
+	err = tx.QueryRowContext(ctx, query, userId).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, tx.Commit()
+}
+
+// MarkRead is a method to mark the notifications ids of a user as read, or all of them when ids is empty.
+func (repository *NotificationRepositoryImpl) MarkRead(ctx context.Context, userId uuid.UUID, ids []int) error {
+	tx, err := helper.BeginTx(ctx, repository.Database, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := "UPDATE notifications SET read_at=now() WHERE user_id=$1 AND read_at IS NULL"
+	params := []interface{}{userId}
+	if len(ids) > 0 {
+		query += " AND id = ANY($2)"
+		params = append(params, pq.Array(ids))
+	}
+	_, err = tx.ExecContext(ctx, query, params...)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetPreferences is a method to retrieve the types of notification a user wants to get.
+func (repository *NotificationRepositoryImpl) GetPreferences(ctx context.Context, userId uuid.UUID) (domain.NotificationPreferences, error) {
+	tx, err := helper.BeginTx(ctx, repository.Database, helper.ReadOnly)
+	if err != nil {
+		return domain.NotificationPreferences{}, err
+	}
+	defer tx.Rollback()
+
+	preferences := domain.NotificationPreferences{
+		UserId:   userId,
+		Likes:    true,
+		Comments: true,
+		Follows:  true,
+		Mentions: true,
+	}
+	query := "SELECT likes, comments, follows, mentions FROM notification_preferences WHERE user_id=$1"
+	err = tx.QueryRowContext(ctx, query, userId).Scan(&preferences.Likes, &preferences.Comments, &preferences.Follows, &preferences.Mentions)
+	if err != nil && err != sql.ErrNoRows {
+		return domain.NotificationPreferences{}, err
+	}
+
+	return preferences, tx.Commit()
+}
+
+// UpdatePreferences is a method to save the types of notification a user wants to get.
+func (repository *NotificationRepositoryImpl) UpdatePreferences(ctx context.Context, preferences domain.NotificationPreferences) (domain.NotificationPreferences, error) {
+	tx, err := helper.BeginTx(ctx, repository.Database, nil)
+	if err != nil {
+		return domain.NotificationPreferences{}, err
+	}
+	defer tx.Rollback()
+
+	query := "INSERT INTO notification_preferences (user_id, likes, comments, follows, mentions) VALUES ($1, $2, $3, $4, $5) " +
+		"ON CONFLICT (user_id) DO UPDATE SET likes=EXCLUDED.likes, comments=EXCLUDED.comments, follows=EXCLUDED.follows, mentions=EXCLUDED.mentions, updated_at=now()"
+	_, err = tx.ExecContext(ctx, query, preferences.UserId, preferences.Likes, preferences.Comments, preferences.Follows, preferences.Mentions)
+	if err != nil {
+		return domain.NotificationPreferences{}, err
+	}
+
+	return preferences, tx.Commit()
+}
@@ -4,12 +4,24 @@ import (
 	"context"
 
 	"github.com/dihanto/gosnap/model/domain"
+	"github.com/google/uuid"
 )
 
 type PhotoRepository interface {
 	PostPhoto(ctx context.Context, photo domain.Photo) (domain.Photo, error)
-	GetPhoto(ctx context.Context, limit int, offset int) ([]domain.Photo, []domain.User, []domain.Like, error)
+	GetPhoto(ctx context.Context, page domain.Page) ([]domain.Photo, []domain.User, []domain.Like, domain.Cursor, error)
+	GetFeed(ctx context.Context, userId uuid.UUID, page domain.Page) ([]domain.Photo, []domain.User, []domain.Like, domain.Cursor, error)
+	GetTagPhotos(ctx context.Context, tag string, page domain.Page) ([]domain.Photo, []domain.User, []domain.Like, domain.Cursor, error)
+	SearchPhotos(ctx context.Context, query string, page domain.Page) ([]domain.Photo, []domain.User, []domain.Like, domain.Cursor, error)
+	SearchTags(ctx context.Context, tag string, page domain.Page) ([]domain.Hashtag, domain.Cursor, error)
 	UpdatePhoto(ctx context.Context, photo domain.Photo) (domain.Photo, error)
 	DeletePhoto(ctx context.Context, id int) error
-	GetPhotoById(ctx context.Context, photoId int) (domain.Photo, domain.User, domain.Like, error)
+	DeleteUserPhotos(ctx context.Context, userId uuid.UUID) error
+	GetPhotoOwner(ctx context.Context, id int) (uuid.UUID, error)
+	GetPhotoById(ctx context.Context, photoId int, userId uuid.UUID, page domain.Page) (domain.PhotoDetail, domain.Cursor, error)
+	GetLegacyPhotos(ctx context.Context, afterId int, limit int) ([]domain.Photo, error)
+	UpdatePhotoKey(ctx context.Context, photo domain.Photo) error
+	GetPhotosWithoutVariants(ctx context.Context, afterId int, limit int) ([]domain.Photo, error)
+	UpdatePhotoVariants(ctx context.Context, photo domain.Photo) error
+	MarkPhotoVariantsFailed(ctx context.Context, id int) error
 }
@@ -3,8 +3,7 @@ package repository
 import (
 	"context"
 	"database/sql"
-	"errors"
-	"time"
+	"strconv"
 
 	"github.com/dihanto/gosnap/internal/app/helper"
 	"github.com/dihanto/gosnap/model/domain"
@@ -23,46 +22,96 @@ func NewPhotoRepository(database *sql.DB) PhotoRepository {
 
 // PostPhoto is a method to create a new photo entry in the database.
 func (repository *PhotoRepositoryImpl) PostPhoto(ctx context.Context, photo domain.Photo) (domain.Photo, error) {
-	tx, err := repository.Database.Begin()
+	tx, err := helper.BeginTx(ctx, repository.Database, nil)
 	if err != nil {
 		return domain.Photo{}, err
 	}
-	defer helper.CommitOrRollback(tx, &err)
+	defer tx.Rollback()
 
-	photo.CreatedAt = int32(time.Now().Unix())
-
-	query := "INSERT INTO photos( title, caption, photo_base64, user_id, created_at) VALUES ($1, $2, $3, $4, $5) RETURNING id"
-	row := tx.QueryRowContext(ctx, query, photo.Title, photo.Caption, photo.PhotoBase64, photo.UserId, photo.CreatedAt)
-	err = row.Scan(&photo.Id)
+	query := "INSERT INTO photos( title, caption, photo_key, user_id) VALUES ($1, $2, $3, $4) RETURNING id, created_at, updated_at"
+	row := tx.QueryRowContext(ctx, query, photo.Title, photo.Caption, photo.PhotoKey, photo.UserId)
+	err = row.Scan(&photo.Id, &photo.CreatedAt, &photo.UpdatedAt)
 	if err != nil {
 		return domain.Photo{}, err
 	}
-	queryLike := "INSERT INTO likes (photo_id) VALUES ($1)"
-	_, err = tx.ExecContext(ctx, queryLike, photo.Id)
+	photo.Entities, err = saveEntities(ctx, tx, photoEntityTables, photo.Id, photo.Entities)
 	if err != nil {
 		return domain.Photo{}, err
 	}
 
-	return photo, nil
+	return photo, tx.Commit()
+}
+
+// GetPhoto is a method to retrieve a page of photo entries, newest first, and their associated users from the database.
+func (repository *PhotoRepositoryImpl) GetPhoto(ctx context.Context, page domain.Page) ([]domain.Photo, []domain.User, []domain.Like, domain.Cursor, error) {
+	tx, err := helper.BeginTx(ctx, repository.Database, helper.ReadOnly)
+	if err != nil {
+		return []domain.Photo{}, []domain.User{}, []domain.Like{}, domain.Cursor{}, err
+	}
+	defer tx.Rollback()
+
+	photos, users, likes, next, err := repository.getPhotoPage(ctx, tx, "", []interface{}{}, page)
+	if err != nil {
+		return []domain.Photo{}, []domain.User{}, []domain.Like{}, domain.Cursor{}, err
+	}
+
+	return photos, users, likes, next, tx.Commit()
+}
+
+// GetFeed is a method to retrieve, newest first, the photos of a user and of the accounts they follow.
+func (repository *PhotoRepositoryImpl) GetFeed(ctx context.Context, userId uuid.UUID, page domain.Page) ([]domain.Photo, []domain.User, []domain.Like, domain.Cursor, error) {
+	tx, err := helper.BeginTx(ctx, repository.Database, helper.ReadOnly)
+	if err != nil {
+		return []domain.Photo{}, []domain.User{}, []domain.Like{}, domain.Cursor{}, err
+	}
+	defer tx.Rollback()
+
+	filter := " AND (photos.user_id = $1 OR photos.user_id IN (SELECT followee_id FROM follows WHERE follower_id = $1))"
+	params := []interface{}{userId}
+	photos, users, likes, next, err := repository.getPhotoPage(ctx, tx, filter, params, page)
+	if err != nil {
+		return []domain.Photo{}, []domain.User{}, []domain.Like{}, domain.Cursor{}, err
+	}
+
+	return photos, users, likes, next, tx.Commit()
 }
 
-// GetPhoto is a method to retrieve all photo entries and their associated users from the database.
-func (repository *PhotoRepositoryImpl) GetPhoto(ctx context.Context, limit int, offset int) ([]domain.Photo, []domain.User, []domain.Like, error) {
-	tx, err := repository.Database.Begin()
+// GetTagPhotos is a method to retrieve, newest first, the photos whose caption has the hashtag tag.
+func (repository *PhotoRepositoryImpl) GetTagPhotos(ctx context.Context, tag string, page domain.Page) ([]domain.Photo, []domain.User, []domain.Like, domain.Cursor, error) {
+	tx, err := helper.BeginTx(ctx, repository.Database, helper.ReadOnly)
 	if err != nil {
-		return []domain.Photo{}, []domain.User{}, []domain.Like{}, err
+		return []domain.Photo{}, []domain.User{}, []domain.Like{}, domain.Cursor{}, err
 	}
-	defer helper.CommitOrRollback(tx, &err)
+	defer tx.Rollback()
 
-	query := "SELECT photos.id, photos.title, photos.caption, photos.photo_base64, photos.user_id, photos.created_at, photos.updated_at, users.username, users.email, users.profile_picture_base64, likes.like_count FROM photos JOIN users ON photos.user_id = users.id JOIN likes ON photos.id = likes.photo_id WHERE photos.deleted_at IS NULL"
-	params := []interface{}{}
-	if offset >= 0 {
-		query += " LIMIT $1 OFFSET $2"
-		params = append(params, limit, offset)
+	filter := " AND photos.id IN (SELECT photo_hashtags.photo_id FROM photo_hashtags JOIN hashtags ON hashtags.id = photo_hashtags.hashtag_id WHERE hashtags.name = $1)"
+	params := []interface{}{tag}
+	photos, users, likes, next, err := repository.getPhotoPage(ctx, tx, filter, params, page)
+	if err != nil {
+		return []domain.Photo{}, []domain.User{}, []domain.Like{}, domain.Cursor{}, err
 	}
+
+	return photos, users, likes, next, tx.Commit()
+}
+
+// getPhotoPage runs the photo listing query narrowed by filter, whose placeholders are bound to params,
+// and pages through it by (created_at, id) so rows don't shift when new photos are posted.
+func (repository *PhotoRepositoryImpl) getPhotoPage(ctx context.Context, tx *helper.Tx, filter string, params []interface{}, page domain.Page) ([]domain.Photo, []domain.User, []domain.Like, domain.Cursor, error) {
+	query := "SELECT " + photoColumns + " FROM photos JOIN users ON photos.user_id = users.id WHERE photos.deleted_at IS NULL" + filter
+	if !page.Cursor.IsZero() {
+		cursorId, err := strconv.Atoi(page.Cursor.Key)
+		if err != nil {
+			return nil, nil, nil, domain.Cursor{}, helper.ErrInvalidCursor
+		}
+		query += " AND (photos.created_at, photos.id) < ($" + strconv.Itoa(len(params)+1) + ", $" + strconv.Itoa(len(params)+2) + ")"
+		params = append(params, page.Cursor.Timestamp(), cursorId)
+	}
+	query += " ORDER BY photos.created_at DESC, photos.id DESC LIMIT $" + strconv.Itoa(len(params)+1)
+	params = append(params, page.Limit+1)
+
 	rows, err := tx.QueryContext(ctx, query, params...)
 	if err != nil {
-		return []domain.Photo{}, []domain.User{}, []domain.Like{}, err
+		return nil, nil, nil, domain.Cursor{}, err
 	}
 	defer rows.Close()
 
@@ -70,59 +119,208 @@ func (repository *PhotoRepositoryImpl) GetPhoto(ctx context.Context, limit int,
 	var photos []domain.Photo
 	var likes []domain.Like
 	for rows.Next() {
-		photo := domain.Photo{}
-		user := domain.User{}
-		like := domain.Like{}
-		err := rows.Scan(&photo.Id, &photo.Title, &photo.Caption, &photo.PhotoBase64, &photo.UserId, &photo.CreatedAt, &photo.UpdatedAt, &user.Username, &user.Email, &user.ProfilePicture, &like.LikeCount)
+		photo, user, like, err := scanPhoto(rows)
 		if err != nil {
-			return []domain.Photo{}, []domain.User{}, []domain.Like{}, err
+			return nil, nil, nil, domain.Cursor{}, err
 		}
-		user.Id = photo.UserId
-		like.PhotoId = photo.Id
 		users = append(users, user)
 		photos = append(photos, photo)
 		likes = append(likes, like)
 	}
-	return photos, users, likes, nil
+	err = rows.Err()
+	if err != nil {
+		return nil, nil, nil, domain.Cursor{}, err
+	}
+
+	next := domain.Cursor{}
+	if len(photos) > page.Limit {
+		photos, users, likes = photos[:page.Limit], users[:page.Limit], likes[:page.Limit]
+		last := photos[len(photos)-1]
+		next = domain.NewTimeCursor(last.CreatedAt, strconv.Itoa(last.Id))
+	}
+	err = attachPhotoEntities(ctx, tx, photos)
+	if err != nil {
+		return nil, nil, nil, domain.Cursor{}, err
+	}
+
+	return photos, users, likes, next, nil
+}
+
+// SearchPhotos is a method to retrieve a page of the photos whose title or caption matches query,
+// a web search style query, most relevant first.
+func (repository *PhotoRepositoryImpl) SearchPhotos(ctx context.Context, query string, page domain.Page) ([]domain.Photo, []domain.User, []domain.Like, domain.Cursor, error) {
+	tx, err := helper.BeginTx(ctx, repository.Database, helper.ReadOnly)
+	if err != nil {
+		return []domain.Photo{}, []domain.User{}, []domain.Like{}, domain.Cursor{}, err
+	}
+	defer tx.Rollback()
+
+	querySearch := "SELECT " + photoColumns + ", photos.rank FROM (" +
+		"SELECT photos.*, ts_rank_cd(photos.search_vector, websearch_to_tsquery('english', $1))::float8 AS rank FROM photos " +
+		"WHERE photos.deleted_at IS NULL AND photos.search_vector @@ websearch_to_tsquery('english', $1)" +
+		") photos JOIN users ON photos.user_id = users.id WHERE users.deleted_at IS NULL"
+	params := []interface{}{query}
+	if !page.Cursor.IsZero() {
+		cursorId, errCursor := strconv.Atoi(page.Cursor.Key)
+		if errCursor != nil {
+			return []domain.Photo{}, []domain.User{}, []domain.Like{}, domain.Cursor{}, helper.ErrInvalidCursor
+		}
+		querySearch += " AND (photos.rank, photos.id) < ($2, $3)"
+		params = append(params, page.Cursor.Rank, cursorId)
+	}
+	querySearch += " ORDER BY photos.rank DESC, photos.id DESC LIMIT $" + strconv.Itoa(len(params)+1)
+	params = append(params, page.Limit+1)
+
+	rows, err := tx.QueryContext(ctx, querySearch, params...)
+	if err != nil {
+		return []domain.Photo{}, []domain.User{}, []domain.Like{}, domain.Cursor{}, err
+	}
+	defer rows.Close()
+
+	photos := []domain.Photo{}
+	var users []domain.User
+	var likes []domain.Like
+	var ranks []float64
+	for rows.Next() {
+		var rank float64
+		photo, user, like, err := scanPhoto(rows, &rank)
+		if err != nil {
+			return []domain.Photo{}, []domain.User{}, []domain.Like{}, domain.Cursor{}, err
+		}
+		photos = append(photos, photo)
+		users = append(users, user)
+		likes = append(likes, like)
+		ranks = append(ranks, rank)
+	}
+	err = rows.Err()
+	if err != nil {
+		return []domain.Photo{}, []domain.User{}, []domain.Like{}, domain.Cursor{}, err
+	}
+
+	next := domain.Cursor{}
+	if len(photos) > page.Limit {
+		photos, users, likes = photos[:page.Limit], users[:page.Limit], likes[:page.Limit]
+		next = domain.NewRankCursor(ranks[page.Limit-1], strconv.Itoa(photos[page.Limit-1].Id))
+	}
+	err = attachPhotoEntities(ctx, tx, photos)
+	if err != nil {
+		return []domain.Photo{}, []domain.User{}, []domain.Like{}, domain.Cursor{}, err
+	}
+
+	return photos, users, likes, next, tx.Commit()
+}
+
+// SearchTags is a method to retrieve a page of the hashtags on live photos that start with or look
+// like tag, most similar first.
+func (repository *PhotoRepositoryImpl) SearchTags(ctx context.Context, tag string, page domain.Page) ([]domain.Hashtag, domain.Cursor, error) {
+	tx, err := helper.BeginTx(ctx, repository.Database, helper.ReadOnly)
+	if err != nil {
+		return []domain.Hashtag{}, domain.Cursor{}, err
+	}
+	defer tx.Rollback()
+
+	query := "SELECT id, name, photo_count, rank FROM (" +
+		"SELECT hashtags.id, hashtags.name, similarity(hashtags.name, $1)::float8 AS rank, " +
+		"(SELECT COUNT(DISTINCT photos.id) FROM photo_hashtags JOIN photos ON photos.id = photo_hashtags.photo_id WHERE photo_hashtags.hashtag_id = hashtags.id AND photos.deleted_at IS NULL) AS photo_count " +
+		"FROM hashtags WHERE hashtags.name % $1 OR hashtags.name LIKE $2" +
+		") results WHERE photo_count > 0"
+	params := []interface{}{tag, helper.LikePrefix(tag)}
+	if !page.Cursor.IsZero() {
+		cursorId, errCursor := strconv.Atoi(page.Cursor.Key)
+		if errCursor != nil {
+			return []domain.Hashtag{}, domain.Cursor{}, helper.ErrInvalidCursor
+		}
+		query += " AND (rank, id) < ($3, $4)"
+		params = append(params, page.Cursor.Rank, cursorId)
+	}
+	query += " ORDER BY rank DESC, id DESC LIMIT $" + strconv.Itoa(len(params)+1)
+	params = append(params, page.Limit+1)
+
+	rows, err := tx.QueryContext(ctx, query, params...)
+	if err != nil {
+		return []domain.Hashtag{}, domain.Cursor{}, err
+	}
+	defer rows.Close()
+
+	hashtags := []domain.Hashtag{}
+	var ranks []float64
+	for rows.Next() {
+		var hashtag domain.Hashtag
+		var rank float64
+		err = rows.Scan(&hashtag.Id, &hashtag.Name, &hashtag.PhotoCount, &rank)
+		if err != nil {
+			return []domain.Hashtag{}, domain.Cursor{}, err
+		}
+		hashtags = append(hashtags, hashtag)
+		ranks = append(ranks, rank)
+	}
+	err = rows.Err()
+	if err != nil {
+		return []domain.Hashtag{}, domain.Cursor{}, err
+	}
+
+	next := domain.Cursor{}
+	if len(hashtags) > page.Limit {
+		hashtags = hashtags[:page.Limit]
+		next = domain.NewRankCursor(ranks[page.Limit-1], strconv.Itoa(hashtags[page.Limit-1].Id))
+	}
+
+	return hashtags, next, tx.Commit()
+}
+
+// photoColumns are the columns scanPhoto reads, for photos joined with their authors as users.
+const photoColumns = "photos.id, photos.title, photos.caption, COALESCE(photos.photo_key, ''), COALESCE(photos.thumbnail_key, ''), COALESCE(photos.medium_key, ''), " +
+	"photos.user_id, photos.created_at, photos.updated_at, users.username, users.email, users.profile_picture_base64, photos.like_count"
+
+// scanPhoto reads a row selected with photoColumns into a photo, its author and its like count.
+// Columns selected after photoColumns are read into extra.
+func scanPhoto(rows *sql.Rows, extra ...interface{}) (domain.Photo, domain.User, domain.Like, error) {
+	photo := domain.Photo{}
+	user := domain.User{}
+	like := domain.Like{}
+	dest := []interface{}{&photo.Id, &photo.Title, &photo.Caption, &photo.PhotoKey, &photo.ThumbnailKey, &photo.MediumKey, &photo.UserId, &photo.CreatedAt, &photo.UpdatedAt, &user.Username, &user.Email, &user.ProfilePicture, &like.LikeCount}
+	err := rows.Scan(append(dest, extra...)...)
+	if err != nil {
+		return domain.Photo{}, domain.User{}, domain.Like{}, err
+	}
+	user.Id = photo.UserId
+	like.PhotoId = photo.Id
+
+	return photo, user, like, nil
 }
 
 // UpdatePhoto is a method to update a photo entry in the database.
 func (repository *PhotoRepositoryImpl) UpdatePhoto(ctx context.Context, photo domain.Photo) (domain.Photo, error) {
-	tx, err := repository.Database.Begin()
+	tx, err := helper.BeginTx(ctx, repository.Database, nil)
 	if err != nil {
 		return domain.Photo{}, err
 	}
-	defer helper.CommitOrRollback(tx, &err)
-
-	photo.UpdatedAt = int32(time.Now().Unix())
+	defer tx.Rollback()
 
-	var userIdResponse uuid.UUID
-	query := "UPDATE photos SET caption=$1, updated_at=$2 WHERE id=$3 RETURNING user_id"
-	row := tx.QueryRowContext(ctx, query, photo.Caption, photo.UpdatedAt, photo.Id)
-	err = row.Scan(&userIdResponse)
+	query := "UPDATE photos SET caption=$1, updated_at=now() WHERE id=$2 AND deleted_at IS NULL RETURNING user_id, title, COALESCE(photo_key, ''), COALESCE(thumbnail_key, ''), COALESCE(medium_key, ''), created_at, updated_at"
+	row := tx.QueryRowContext(ctx, query, photo.Caption, photo.Id)
+	err = row.Scan(&photo.UserId, &photo.Title, &photo.PhotoKey, &photo.ThumbnailKey, &photo.MediumKey, &photo.CreatedAt, &photo.UpdatedAt)
 	if err != nil {
 		return domain.Photo{}, err
 	}
-	if photo.UserId != userIdResponse {
-		errr := errors.New("unauthorized")
-		return domain.Photo{}, errr
+	photo.Entities, err = saveEntities(ctx, tx, photoEntityTables, photo.Id, photo.Entities)
+	if err != nil {
+		return domain.Photo{}, err
 	}
 
-	return photo, nil
+	return photo, tx.Commit()
 }
 
 // DeletePhoto is a method to "soft delete" a photo entry by setting the deleted_at field in the database.
 func (repository *PhotoRepositoryImpl) DeletePhoto(ctx context.Context, id int) error {
-	tx, err := repository.Database.Begin()
+	tx, err := helper.BeginTx(ctx, repository.Database, nil)
 	if err != nil {
 		return err
 	}
-	defer helper.CommitOrRollback(tx, &err)
-
-	deleteTime := int32(time.Now().Unix())
+	defer tx.Rollback()
 
-	query := "UPDATE photos SET deleted_at=$1 WHERE id=$2"
-	result, err := tx.ExecContext(ctx, query, deleteTime, id)
+	query := "UPDATE photos SET deleted_at=now() WHERE id=$1 AND deleted_at IS NULL"
+	result, err := tx.ExecContext(ctx, query, id)
 	if err != nil {
 		return err
 	}
@@ -131,40 +329,266 @@ func (repository *PhotoRepositoryImpl) DeletePhoto(ctx context.Context, id int)
 		return err
 	}
 	if rows == 0 {
-		return errors.New("photo media not found")
+		return ErrPhotoNotFound
 	}
 
-	return nil
+	return tx.Commit()
 }
 
-func (repository *PhotoRepositoryImpl) GetPhotoById(ctx context.Context, photoId int) (domain.Photo, domain.User, domain.Like, error) {
-	tx, err := repository.Database.Begin()
+// DeleteUserPhotos is a method to "soft delete" every photo of a user.
+func (repository *PhotoRepositoryImpl) DeleteUserPhotos(ctx context.Context, userId uuid.UUID) error {
+	tx, err := helper.BeginTx(ctx, repository.Database, nil)
 	if err != nil {
-		return domain.Photo{}, domain.User{}, domain.Like{}, err
+		return err
 	}
-	defer helper.CommitOrRollback(tx, &err)
+	defer tx.Rollback()
 
-	photo := domain.Photo{}
-	photoQuery := "SELECT caption, photo_base64, user_id FROM photos WHERE id=$1"
-	err = tx.QueryRowContext(ctx, photoQuery, photoId).Scan(&photo.Caption, &photo.PhotoBase64, &photo.UserId)
+	query := "UPDATE photos SET deleted_at=now() WHERE user_id=$1 AND deleted_at IS NULL"
+	_, err = tx.ExecContext(ctx, query, userId)
 	if err != nil {
-		return domain.Photo{}, domain.User{}, domain.Like{}, err
+		return err
 	}
 
-	user := domain.User{}
-	userQuery := "SELECT username, name, profile_picture_base64 FROM users WHERE id=$1"
-	err = tx.QueryRowContext(ctx, userQuery, photo.UserId).Scan(&user.Username, &user.Name, &user.ProfilePicture)
+	return tx.Commit()
+}
+
+// GetPhotoById is a method to retrieve a photo with its author, like count, whether userId liked it
+// and a page of the comments that aren't replies, newest first, in a single query.
+func (repository *PhotoRepositoryImpl) GetPhotoById(ctx context.Context, photoId int, userId uuid.UUID, page domain.Page) (domain.PhotoDetail, domain.Cursor, error) {
+	tx, err := helper.BeginTx(ctx, repository.Database, helper.ReadOnly)
 	if err != nil {
-		return domain.Photo{}, domain.User{}, domain.Like{}, err
+		return domain.PhotoDetail{}, domain.Cursor{}, err
 	}
+	defer tx.Rollback()
 
-	like := domain.Like{}
-	likeQuery := "SELECT like_count from likes WHERE photo_id=$1"
-	err = tx.QueryRowContext(ctx, likeQuery, photoId).Scan(&like.LikeCount)
+	params := []interface{}{photoId, userId}
+	commentFilter := ""
+	if !page.Cursor.IsZero() {
+		cursorId, errCursor := strconv.Atoi(page.Cursor.Key)
+		if errCursor != nil {
+			err = helper.ErrInvalidCursor
+			return domain.PhotoDetail{}, domain.Cursor{}, err
+		}
+		commentFilter = " AND (comments.created_at, comments.id) < ($3, $4)"
+		params = append(params, page.Cursor.Timestamp(), cursorId)
+	}
+	params = append(params, page.Limit+1)
+
+	query := "SELECT photos.id, photos.title, photos.caption, COALESCE(photos.photo_key, ''), COALESCE(photos.thumbnail_key, ''), COALESCE(photos.medium_key, ''), photos.user_id, photos.created_at, photos.updated_at, " +
+		"users.username, users.name, users.profile_picture_base64, photos.like_count, " +
+		"EXISTS (SELECT 1 FROM photo_likes WHERE photo_likes.photo_id = photos.id AND photo_likes.user_id = $2), " +
+		"photo_comments.id, photo_comments.message, photo_comments.user_id, photo_comments.created_at, photo_comments.updated_at, photo_comments.reply_count, photo_comments.like_count, photo_comments.email, photo_comments.username " +
+		"FROM photos JOIN users ON photos.user_id = users.id " +
+		"LEFT JOIN LATERAL (SELECT comments.id, comments.message, comments.user_id, comments.created_at, comments.updated_at, " +
+		"(SELECT COUNT(*) FROM comments replies WHERE replies.parent_id = comments.id AND replies.deleted_at IS NULL) AS reply_count, comments.like_count, commenters.email, commenters.username FROM comments JOIN users commenters ON comments.user_id = commenters.id " +
+		"WHERE comments.photo_id = photos.id AND comments.parent_id IS NULL AND comments.deleted_at IS NULL" + commentFilter + " ORDER BY comments.created_at DESC, comments.id DESC LIMIT $" + strconv.Itoa(len(params)) + ") photo_comments ON true " +
+		"WHERE photos.id = $1 AND photos.deleted_at IS NULL ORDER BY photo_comments.created_at DESC, photo_comments.id DESC"
+	rows, err := tx.QueryContext(ctx, query, params...)
 	if err != nil {
-		return domain.Photo{}, domain.User{}, domain.Like{}, err
+		return domain.PhotoDetail{}, domain.Cursor{}, err
 	}
+	defer rows.Close()
 
-	return photo, user, like, nil
+	detail := domain.PhotoDetail{}
+	found := false
+	for rows.Next() {
+		var commentId, commentReplyCount, commentLikeCount sql.NullInt64
+		var commentMessage, commenterEmail, commenterUsername sql.NullString
+		var commentCreatedAt, commentUpdatedAt sql.NullTime
+		var commenterId uuid.NullUUID
+		err = rows.Scan(&detail.Photo.Id, &detail.Photo.Title, &detail.Photo.Caption, &detail.Photo.PhotoKey, &detail.Photo.ThumbnailKey, &detail.Photo.MediumKey, &detail.Photo.UserId, &detail.Photo.CreatedAt, &detail.Photo.UpdatedAt,
+			&detail.User.Username, &detail.User.Name, &detail.User.ProfilePicture, &detail.Like.LikeCount, &detail.Liked,
+			&commentId, &commentMessage, &commenterId, &commentCreatedAt, &commentUpdatedAt, &commentReplyCount, &commentLikeCount, &commenterEmail, &commenterUsername)
+		if err != nil {
+			return domain.PhotoDetail{}, domain.Cursor{}, err
+		}
+		found = true
+		if !commentId.Valid {
+			continue
+		}
+
+		detail.Comments = append(detail.Comments, domain.Comment{
+			Id:         int(commentId.Int64),
+			UserId:     commenterId.UUID,
+			PhotoId:    photoId,
+			Message:    commentMessage.String,
+			ReplyCount: int(commentReplyCount.Int64),
+			LikeCount:  int(commentLikeCount.Int64),
+			CreatedAt:  commentCreatedAt.Time,
+			UpdatedAt:  commentUpdatedAt.Time,
+		})
+		detail.Commenters = append(detail.Commenters, domain.User{
+			Id:       commenterId.UUID,
+			Email:    commenterEmail.String,
+			Username: commenterUsername.String,
+		})
+	}
+	err = rows.Err()
+	if err != nil {
+		return domain.PhotoDetail{}, domain.Cursor{}, err
+	}
+	if !found {
+		err = ErrPhotoNotFound
+		return domain.PhotoDetail{}, domain.Cursor{}, err
+	}
+	detail.User.Id = detail.Photo.UserId
+	detail.Like.PhotoId = detail.Photo.Id
+
+	next := domain.Cursor{}
+	if len(detail.Comments) > page.Limit {
+		detail.Comments, detail.Commenters = detail.Comments[:page.Limit], detail.Commenters[:page.Limit]
+		last := detail.Comments[len(detail.Comments)-1]
+		next = domain.NewTimeCursor(last.CreatedAt, strconv.Itoa(last.Id))
+	}
+	photos := []domain.Photo{detail.Photo}
+	err = attachPhotoEntities(ctx, tx, photos)
+	if err != nil {
+		return domain.PhotoDetail{}, domain.Cursor{}, err
+	}
+	detail.Photo = photos[0]
+	err = attachCommentEntities(ctx, tx, detail.Comments)
+	if err != nil {
+		return domain.PhotoDetail{}, domain.Cursor{}, err
+	}
+
+	return detail, next, tx.Commit()
+}
+
+// GetLegacyPhotos is a method to retrieve photos, after the given id, that still keep their image in the photo_base64 column.
+func (repository *PhotoRepositoryImpl) GetLegacyPhotos(ctx context.Context, afterId int, limit int) ([]domain.Photo, error) {
+	tx, err := helper.BeginTx(ctx, repository.Database, helper.ReadOnly)
+	if err != nil {
+		return []domain.Photo{}, err
+	}
+	defer tx.Rollback()
+
+	query := "SELECT id, user_id, photo_base64 FROM photos WHERE id > $1 AND photo_key IS NULL AND photo_base64 IS NOT NULL ORDER BY id LIMIT $2"
+	rows, err := tx.QueryContext(ctx, query, afterId, limit)
+	if err != nil {
+		return []domain.Photo{}, err
+	}
+	defer rows.Close()
+
+	var photos []domain.Photo
+	for rows.Next() {
+		photo := domain.Photo{}
+		err = rows.Scan(&photo.Id, &photo.UserId, &photo.PhotoBase64)
+		if err != nil {
+			return []domain.Photo{}, err
+		}
+		photos = append(photos, photo)
+	}
+	err = rows.Err()
+	if err != nil {
+		return []domain.Photo{}, err
+	}
+
+	return photos, tx.Commit()
+}
+
+// UpdatePhotoKey is a method to point a photo at its object in blob storage and drop the inline base64 copy.
+func (repository *PhotoRepositoryImpl) UpdatePhotoKey(ctx context.Context, photo domain.Photo) error {
+	tx, err := helper.BeginTx(ctx, repository.Database, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := "UPDATE photos SET photo_key=$1, photo_base64=NULL WHERE id=$2"
+	_, err = tx.ExecContext(ctx, query, photo.PhotoKey, photo.Id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetPhotosWithoutVariants is a method to retrieve photos, after the given id, whose resized variants have not been generated
+// yet and whose generation didn't fail before.
+func (repository *PhotoRepositoryImpl) GetPhotosWithoutVariants(ctx context.Context, afterId int, limit int) ([]domain.Photo, error) {
+	tx, err := helper.BeginTx(ctx, repository.Database, helper.ReadOnly)
+	if err != nil {
+		return []domain.Photo{}, err
+	}
+	defer tx.Rollback()
+
+	query := "SELECT id, photo_key FROM photos WHERE id > $1 AND photo_key IS NOT NULL AND thumbnail_key IS NULL AND variants_failed_at IS NULL AND deleted_at IS NULL ORDER BY id LIMIT $2"
+	rows, err := tx.QueryContext(ctx, query, afterId, limit)
+	if err != nil {
+		return []domain.Photo{}, err
+	}
+	defer rows.Close()
+
+	var photos []domain.Photo
+	for rows.Next() {
+		photo := domain.Photo{}
+		err = rows.Scan(&photo.Id, &photo.PhotoKey)
+		if err != nil {
+			return []domain.Photo{}, err
+		}
+		photos = append(photos, photo)
+	}
+	err = rows.Err()
+	if err != nil {
+		return []domain.Photo{}, err
+	}
+
+	return photos, tx.Commit()
+}
+
+// UpdatePhotoVariants is a method to store the object keys of a photo's generated variants.
+func (repository *PhotoRepositoryImpl) UpdatePhotoVariants(ctx context.Context, photo domain.Photo) error {
+	tx, err := helper.BeginTx(ctx, repository.Database, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := "UPDATE photos SET photo_key=$1, thumbnail_key=$2, medium_key=$3 WHERE id=$4"
+	_, err = tx.ExecContext(ctx, query, photo.PhotoKey, photo.ThumbnailKey, photo.MediumKey, photo.Id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// MarkPhotoVariantsFailed is a method to record that the variants of a photo can't be generated, so it isn't retried.
+func (repository *PhotoRepositoryImpl) MarkPhotoVariantsFailed(ctx context.Context, id int) error {
+	tx, err := helper.BeginTx(ctx, repository.Database, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := "UPDATE photos SET variants_failed_at=now() WHERE id=$1"
+	_, err = tx.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetPhotoOwner is a method to retrieve the id of the user who owns a photo. The row stays locked until the
+// transaction it runs in ends, so a caller that checks the owner within a unit of work can act on the row knowing it
+// hasn't changed since.
+func (repository *PhotoRepositoryImpl) GetPhotoOwner(ctx context.Context, id int) (uuid.UUID, error) {
+	tx, err := helper.BeginTx(ctx, repository.Database, nil)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	defer tx.Rollback()
+
+	var userId uuid.UUID
+	query := "SELECT user_id FROM photos WHERE id=$1 AND deleted_at IS NULL FOR UPDATE"
+	err = tx.QueryRowContext(ctx, query, id).Scan(&userId)
+	if err == sql.ErrNoRows {
+		return uuid.Nil, ErrPhotoNotFound
+	}
+	if err != nil {
+		return uuid.Nil, err
+	}
 
+	return userId, tx.Commit()
 }
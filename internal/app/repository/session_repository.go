@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/dihanto/gosnap/model/domain"
+	"github.com/google/uuid"
+)
+
+// ErrRefreshTokenReused is returned when a refresh token that was already rotated or revoked is presented again.
+var ErrRefreshTokenReused = errors.New("refresh token reused")
+
+type SessionRepository interface {
+	CreateRefreshToken(ctx context.Context, token domain.RefreshToken) error
+	GetRefreshToken(ctx context.Context, tokenHash string) (domain.RefreshToken, domain.User, error)
+	RotateRefreshToken(ctx context.Context, current domain.RefreshToken, next domain.RefreshToken) error
+	RevokeRefreshTokenFamily(ctx context.Context, familyId uuid.UUID) error
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/dihanto/gosnap/internal/app/helper"
+	"github.com/dihanto/gosnap/model/domain"
+	"github.com/google/uuid"
+)
+
+type SessionRepositoryImpl struct {
+	Database *sql.DB
+}
+
+func NewSessionRepository(database *sql.DB) SessionRepository {
+	return &SessionRepositoryImpl{
+		Database: database,
+	}
+}
+
+// CreateRefreshToken is a method to store a newly issued refresh token in the database.
+func (repository *SessionRepositoryImpl) CreateRefreshToken(ctx context.Context, token domain.RefreshToken) error {
+	tx, err := helper.BeginTx(ctx, repository.Database, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := "INSERT INTO refresh_tokens (id, user_id, family_id, token_hash, created_at, expires_at) VALUES ($1, $2, $3, $4, $5, $6)"
+	_, err = tx.ExecContext(ctx, query, token.Id, token.UserId, token.FamilyId, token.TokenHash, token.CreatedAt, token.ExpiresAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetRefreshToken is a method to retrieve a refresh token by its hash together with the active user it belongs to.
+func (repository *SessionRepositoryImpl) GetRefreshToken(ctx context.Context, tokenHash string) (domain.RefreshToken, domain.User, error) {
+	tx, err := helper.BeginTx(ctx, repository.Database, helper.ReadOnly)
+	if err != nil {
+		return domain.RefreshToken{}, domain.User{}, err
+	}
+	defer tx.Rollback()
+
+	token := domain.RefreshToken{}
+	user := domain.User{}
+	query := "SELECT refresh_tokens.id, refresh_tokens.user_id, refresh_tokens.family_id, refresh_tokens.token_hash, refresh_tokens.created_at, refresh_tokens.expires_at, refresh_tokens.revoked_at, users.username, users.role FROM refresh_tokens JOIN users ON refresh_tokens.user_id = users.id WHERE refresh_tokens.token_hash = $1 AND users.deleted_at IS NULL AND users.suspended_at IS NULL"
+	err = tx.QueryRowContext(ctx, query, tokenHash).Scan(&token.Id, &token.UserId, &token.FamilyId, &token.TokenHash, &token.CreatedAt, &token.ExpiresAt, &token.RevokedAt, &user.Username, &user.Role)
+	if err != nil {
+		return domain.RefreshToken{}, domain.User{}, err
+	}
+	user.Id = token.UserId
+
+	return token, user, tx.Commit()
+}
+
+// RotateRefreshToken is a method to revoke the current refresh token and store the next one of the same family.
+// It fails with ErrRefreshTokenReused if the current token was revoked in the meantime.
+func (repository *SessionRepositoryImpl) RotateRefreshToken(ctx context.Context, current domain.RefreshToken, next domain.RefreshToken) error {
+	tx, err := helper.BeginTx(ctx, repository.Database, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	queryRevoke := "UPDATE refresh_tokens SET revoked_at = $1 WHERE id = $2 AND revoked_at IS NULL"
+	result, err := tx.ExecContext(ctx, queryRevoke, next.CreatedAt, current.Id)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		err = ErrRefreshTokenReused
+		return err
+	}
+
+	queryInsert := "INSERT INTO refresh_tokens (id, user_id, family_id, token_hash, created_at, expires_at) VALUES ($1, $2, $3, $4, $5, $6)"
+	_, err = tx.ExecContext(ctx, queryInsert, next.Id, next.UserId, next.FamilyId, next.TokenHash, next.CreatedAt, next.ExpiresAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RevokeRefreshTokenFamily is a method to revoke every refresh token descended from the same login.
+func (repository *SessionRepositoryImpl) RevokeRefreshTokenFamily(ctx context.Context, familyId uuid.UUID) error {
+	tx, err := helper.BeginTx(ctx, repository.Database, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := "UPDATE refresh_tokens SET revoked_at = now() WHERE family_id = $1 AND revoked_at IS NULL"
+	_, err = tx.ExecContext(ctx, query, familyId)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
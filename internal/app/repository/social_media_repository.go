@@ -4,11 +4,13 @@ import (
 	"context"
 
 	"github.com/dihanto/gosnap/model/domain"
+	"github.com/google/uuid"
 )
 
 type SocialMediaRepository interface {
 	PostSocialMedia(ctx context.Context, socialMedia domain.SocialMedia) (domain.SocialMedia, error)
-	GetSocialMedia(ctx context.Context) ([]domain.SocialMedia, []domain.User, error)
+	GetSocialMedia(ctx context.Context, page domain.Page) ([]domain.SocialMedia, []domain.User, domain.Cursor, error)
 	UpdateSocialMedia(ctx context.Context, socialMedia domain.SocialMedia) (domain.SocialMedia, error)
 	DeleteSocialMedia(ctx context.Context, id int) error
+	GetSocialMediaOwner(ctx context.Context, id int) (uuid.UUID, error)
 }
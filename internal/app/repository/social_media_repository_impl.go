@@ -3,11 +3,11 @@ package repository
 import (
 	"context"
 	"database/sql"
-	"errors"
-	"time"
+	"strconv"
 
 	"github.com/dihanto/gosnap/internal/app/helper"
 	"github.com/dihanto/gosnap/model/domain"
+	"github.com/google/uuid"
 )
 
 type SocialMediaRepositoryImpl struct {
@@ -22,36 +22,47 @@ func NewSocialMediaRepository(database *sql.DB) SocialMediaRepository {
 
 // PostSocialMedia is a method to create a new social media entry in the database.
 func (repository *SocialMediaRepositoryImpl) PostSocialMedia(ctx context.Context, socialMedia domain.SocialMedia) (domain.SocialMedia, error) {
-	tx, err := repository.Database.Begin()
+	tx, err := helper.BeginTx(ctx, repository.Database, nil)
 	if err != nil {
 		return domain.SocialMedia{}, err
 	}
-	defer helper.CommitOrRollback(tx, &err)
+	defer tx.Rollback()
 
-	socialMedia.CreatedAt = int32(time.Now().Unix())
-
-	query := "INSERT INTO social_medias (name, social_media_url, user_id, created_at) VALUES ($1, $2, $3, $4) RETURNING id"
-	row := tx.QueryRowContext(ctx, query, socialMedia.Name, socialMedia.SocialMediaUrl, socialMedia.UserId, socialMedia.CreatedAt)
-	err = row.Scan(&socialMedia.Id)
+	query := "INSERT INTO social_medias (name, social_media_url, user_id) VALUES ($1, $2, $3) RETURNING id, created_at, updated_at"
+	row := tx.QueryRowContext(ctx, query, socialMedia.Name, socialMedia.SocialMediaUrl, socialMedia.UserId)
+	err = row.Scan(&socialMedia.Id, &socialMedia.CreatedAt, &socialMedia.UpdatedAt)
 	if err != nil {
 		return domain.SocialMedia{}, err
 	}
 
-	return socialMedia, nil
+	return socialMedia, tx.Commit()
 }
 
-// GetSocialMedia is a method to retrieve all social media entries and their associated users from the database.
-func (repository *SocialMediaRepositoryImpl) GetSocialMedia(ctx context.Context) ([]domain.SocialMedia, []domain.User, error) {
-	tx, err := repository.Database.Begin()
+// GetSocialMedia is a method to retrieve a page of social media entries, newest first, and their associated users from the database.
+func (repository *SocialMediaRepositoryImpl) GetSocialMedia(ctx context.Context, page domain.Page) ([]domain.SocialMedia, []domain.User, domain.Cursor, error) {
+	tx, err := helper.BeginTx(ctx, repository.Database, helper.ReadOnly)
 	if err != nil {
-		return []domain.SocialMedia{}, []domain.User{}, err
+		return []domain.SocialMedia{}, []domain.User{}, domain.Cursor{}, err
+	}
+	defer tx.Rollback()
+
+	query := "SELECT social_medias.id, social_medias.name, social_medias.social_media_url, social_medias.user_id, social_medias.created_at, social_medias.updated_at, users.id, users.username FROM social_medias JOIN users ON social_medias.user_id = users.id WHERE social_medias.deleted_at IS NULL"
+	params := []interface{}{}
+	if !page.Cursor.IsZero() {
+		cursorId, errCursor := strconv.Atoi(page.Cursor.Key)
+		if errCursor != nil {
+			err = helper.ErrInvalidCursor
+			return []domain.SocialMedia{}, []domain.User{}, domain.Cursor{}, err
+		}
+		query += " AND (social_medias.created_at, social_medias.id) < ($1, $2)"
+		params = append(params, page.Cursor.Timestamp(), cursorId)
 	}
-	defer helper.CommitOrRollback(tx, &err)
+	query += " ORDER BY social_medias.created_at DESC, social_medias.id DESC LIMIT $" + strconv.Itoa(len(params)+1)
+	params = append(params, page.Limit+1)
 
-	query := "SELECT social_medias.id, social_medias.name, social_medias.social_media_url, social_medias.user_id, social_medias.created_at, social_medias.updated_at, users.id, users.username FROM social_medias JOIN users ON social_medias.user_id = users.id WHERE social_medias.deleted_at IS NULL;"
-	rows, err := tx.QueryContext(ctx, query)
+	rows, err := tx.QueryContext(ctx, query, params...)
 	if err != nil {
-		return []domain.SocialMedia{}, []domain.User{}, err
+		return []domain.SocialMedia{}, []domain.User{}, domain.Cursor{}, err
 	}
 	defer rows.Close()
 
@@ -62,49 +73,56 @@ func (repository *SocialMediaRepositoryImpl) GetSocialMedia(ctx context.Context)
 		user := domain.User{}
 		err = rows.Scan(&socialMedia.Id, &socialMedia.Name, &socialMedia.SocialMediaUrl, &socialMedia.UserId, &socialMedia.CreatedAt, &socialMedia.UpdatedAt, &user.Id, &user.Username)
 		if err != nil {
-			return []domain.SocialMedia{}, []domain.User{}, err
+			return []domain.SocialMedia{}, []domain.User{}, domain.Cursor{}, err
 		}
 		user.Id = socialMedia.UserId
 		users = append(users, user)
 		socialMedias = append(socialMedias, socialMedia)
 	}
+	err = rows.Err()
+	if err != nil {
+		return []domain.SocialMedia{}, []domain.User{}, domain.Cursor{}, err
+	}
 
-	return socialMedias, users, nil
+	next := domain.Cursor{}
+	if len(socialMedias) > page.Limit {
+		socialMedias, users = socialMedias[:page.Limit], users[:page.Limit]
+		last := socialMedias[len(socialMedias)-1]
+		next = domain.NewTimeCursor(last.CreatedAt, strconv.Itoa(last.Id))
+	}
+
+	return socialMedias, users, next, tx.Commit()
 }
 
 // UpdateSocialMedia is a method to update a social media entry in the database.
 func (repository *SocialMediaRepositoryImpl) UpdateSocialMedia(ctx context.Context, socialMedia domain.SocialMedia) (domain.SocialMedia, error) {
-	tx, err := repository.Database.Begin()
+	tx, err := helper.BeginTx(ctx, repository.Database, nil)
 	if err != nil {
 		return domain.SocialMedia{}, err
 	}
-	defer helper.CommitOrRollback(tx, &err)
+	defer tx.Rollback()
 
-	socialMedia.UpdatedAt = int32(time.Now().Unix())
+	query := "UPDATE social_medias SET name=$1, social_media_url=$2, updated_at=now() WHERE id=$3 AND deleted_at IS NULL RETURNING user_id, created_at, updated_at"
+	row := tx.QueryRowContext(ctx, query, socialMedia.Name, socialMedia.SocialMediaUrl, socialMedia.Id)
 
-	query := "UPDATE social_medias SET name=$1, social_media_url=$2, updated_at=$3 WHERE id=$4 RETURNING user_id"
-	row := tx.QueryRowContext(ctx, query, socialMedia.Name, socialMedia.SocialMediaUrl, socialMedia.UpdatedAt, socialMedia.Id)
-
-	err = row.Scan(&socialMedia.UserId)
+	err = row.Scan(&socialMedia.UserId, &socialMedia.CreatedAt, &socialMedia.UpdatedAt)
 	if err != nil {
 		return domain.SocialMedia{}, err
 	}
 
-	return socialMedia, nil
+	return socialMedia, tx.Commit()
 }
 
 // DeleteSocialMedia is a method to "soft delete" a social media entry by setting the deleted_at field in the database.
 func (repository *SocialMediaRepositoryImpl) DeleteSocialMedia(ctx context.Context, id int) error {
-	tx, err := repository.Database.Begin()
+	tx, err := helper.BeginTx(ctx, repository.Database, nil)
 	if err != nil {
 		return err
 	}
-	defer helper.CommitOrRollback(tx, &err)
-
-	deleteTime := int32(time.Now().Unix())
+	defer tx.Rollback()
 
-	query := "UPDATE social_medias SET deleted_at=$1 WHERE id=$2"
-	result, err := tx.ExecContext(ctx, query, deleteTime, id)
+	query := "UPDATE social_medias SET deleted_at=now() WHERE id=$1 AND deleted_at IS NULL"
+	result, err := tx.ExecContext(ctx, query, id)
 	if err != nil {
 		return err
 	}
@@ -113,8 +131,31 @@ func (repository *SocialMediaRepositoryImpl) DeleteSocialMedia(ctx context.Conte
 		return err
 	}
 	if rows == 0 {
-		return errors.New("social media not found")
+		return ErrSocialMediaNotFound
+	}
+
+	return tx.Commit()
+}
+
+// GetSocialMediaOwner is a method to retrieve the id of the user who owns a social media entry. The row stays locked until the
+// transaction it runs in ends, so a caller that checks the owner within a unit of work can act on the row knowing it
+// hasn't changed since.
+func (repository *SocialMediaRepositoryImpl) GetSocialMediaOwner(ctx context.Context, id int) (uuid.UUID, error) {
+	tx, err := helper.BeginTx(ctx, repository.Database, nil)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	defer tx.Rollback()
+
+	var userId uuid.UUID
+	query := "SELECT user_id FROM social_medias WHERE id=$1 AND deleted_at IS NULL FOR UPDATE"
+	err = tx.QueryRowContext(ctx, query, id).Scan(&userId)
+	if err == sql.ErrNoRows {
+		return uuid.Nil, ErrSocialMediaNotFound
+	}
+	if err != nil {
+		return uuid.Nil, err
 	}
 
-	return nil
+	return userId, tx.Commit()
 }
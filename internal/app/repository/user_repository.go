@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/dihanto/gosnap/model/domain"
 	"github.com/google/uuid"
@@ -9,9 +10,17 @@ import (
 
 type UserRepository interface {
 	UserRegister(ctx context.Context, user domain.User) (domain.User, error)
-	UserLogin(ctx context.Context, username string, password string) (bool, uuid.UUID, error)
+	UserLogin(ctx context.Context, username string, password string) (bool, domain.User, error)
 	UserUpdate(ctx context.Context, user domain.User) (domain.User, error)
 	UserDelete(ctx context.Context, id uuid.UUID) error
 	FindUser(ctx context.Context, id uuid.UUID) (user domain.User, err error)
-	FindAllUser(ctx context.Context) (users []domain.User, err error)
+	SuspendUser(ctx context.Context, id uuid.UUID) error
+	UnsuspendUser(ctx context.Context, id uuid.UUID) error
+	UpdateUserRole(ctx context.Context, id uuid.UUID, role string) error
+	FindAllUser(ctx context.Context, excludeId uuid.UUID, page domain.Page) (users []domain.User, next domain.Cursor, err error)
+	FindUserByUsername(ctx context.Context, username string) (user domain.User, err error)
+	UsernameReserved(ctx context.Context, username string, userId uuid.UUID, since time.Time) (bool, error)
+	LockUsername(ctx context.Context, username string) error
+	GetMentions(ctx context.Context, userId uuid.UUID, page domain.Page) ([]domain.Mention, []domain.User, domain.Cursor, error)
+	SearchUsers(ctx context.Context, query string, page domain.Page) ([]domain.User, domain.Cursor, error)
 }
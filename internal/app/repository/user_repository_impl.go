@@ -3,13 +3,14 @@ package repository
 import (
 	"context"
 	"database/sql"
-	"errors"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/dihanto/gosnap/internal/app/helper"
 	"github.com/dihanto/gosnap/model/domain"
 	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
 )
 
 type UserRepositoryImpl struct {
@@ -24,70 +25,82 @@ func NewUserRepository(database *sql.DB) UserRepository {
 
 // UserRegister is a method to register a new user in the database.
 func (repository *UserRepositoryImpl) UserRegister(ctx context.Context, user domain.User) (domain.User, error) {
-	tx, err := repository.Database.Begin()
+	tx, err := helper.BeginTx(ctx, repository.Database, nil)
 	if err != nil {
 		return domain.User{}, err
 	}
-	defer helper.CommitOrRollback(tx, &err)
-
-	user.CreatedAt = int32(time.Now().Unix())
+	defer tx.Rollback()
 
 	password, err := helper.HashPassword(user.Password)
 	if err != nil {
 		return domain.User{}, err
 	}
 
-	query := "INSERT INTO users (id, username, name, email, password, created_at) VALUES ($1, $2, $3, $4, $5, $6)"
-	_, err = tx.ExecContext(ctx, query, user.Id, user.Username, user.Name, user.Email, password, user.CreatedAt)
-	if err != nil {
-		return domain.User{}, err
-	}
-
-	queryFollow := "INSERT INTO followers (user_id, username) VALUES ($1, $2)"
-	_, err = tx.ExecContext(ctx, queryFollow, user.Id, user.Username)
+	query := "INSERT INTO users (id, username, name, email, password) VALUES ($1, $2, $3, $4, $5) RETURNING created_at, updated_at"
+	err = tx.QueryRowContext(ctx, query, user.Id, user.Username, user.Name, user.Email, password).Scan(&user.CreatedAt, &user.UpdatedAt)
 	if err != nil {
+		err = userConflict(err)
 		return domain.User{}, err
 	}
 
-	return user, nil
+	return user, tx.Commit()
 }
 
 // UserLogin is a method to authenticate a user during login.
-func (repository *UserRepositoryImpl) UserLogin(ctx context.Context, username string, password string) (bool, uuid.UUID, error) {
-	tx, err := repository.Database.Begin()
+func (repository *UserRepositoryImpl) UserLogin(ctx context.Context, username string, password string) (bool, domain.User, error) {
+	tx, err := helper.BeginTx(ctx, repository.Database, helper.ReadOnly)
 	if err != nil {
-		return false, uuid.Nil, err
+		return false, domain.User{}, err
 	}
-	defer helper.CommitOrRollback(tx, &err)
+	defer tx.Rollback()
 
 	var pwd string
-	var id uuid.UUID
-	query := "SELECT password, id FROM users WHERE username = $1 AND deleted_at is NULL;"
-	err = tx.QueryRowContext(ctx, query, username).Scan(&pwd, &id)
+	user := domain.User{}
+	query := "SELECT password, id, username, role, suspended_at FROM users WHERE username = $1 AND deleted_at is NULL;"
+	err = tx.QueryRowContext(ctx, query, username).Scan(&pwd, &user.Id, &user.Username, &user.Role, &user.SuspendedAt)
 	if err == sql.ErrNoRows {
-		return false, uuid.Nil, err
+		return false, domain.User{}, ErrUserNotFound
 	}
 	if err != nil {
-		return false, uuid.Nil, err
+		return false, domain.User{}, err
 	}
 
 	match, err := helper.CheckPasswordHash(password, pwd)
+	if err == bcrypt.ErrMismatchedHashAndPassword {
+		return false, domain.User{}, ErrPasswordMismatch
+	}
 	if !match {
-		return false, uuid.Nil, err
+		return false, domain.User{}, err
 	}
 
-	return true, id, nil
+	return true, user, tx.Commit()
 }
 
 // UserUpdate is a method to update user information in the database.
 func (repository *UserRepositoryImpl) UserUpdate(ctx context.Context, user domain.User) (domain.User, error) {
-	tx, err := repository.Database.Begin()
+	tx, err := helper.BeginTx(ctx, repository.Database, nil)
+	if err != nil {
+		return domain.User{}, err
+	}
+	defer tx.Rollback()
+
+	var oldUsername string
+	queryCurrent := "SELECT username FROM users WHERE id=$1 AND deleted_at IS NULL FOR UPDATE"
+	err = tx.QueryRowContext(ctx, queryCurrent, user.Id).Scan(&oldUsername)
+	if err == sql.ErrNoRows {
+		err = ErrUserNotFound
+	}
 	if err != nil {
-		return domain.User{}, nil
+		return domain.User{}, err
+	}
+	// someone checking whether the old username is reserved waits for it to be recorded below
+	if user.Username != "" && user.Username != oldUsername {
+		err = lockUsername(ctx, tx, oldUsername)
+		if err != nil {
+			return domain.User{}, err
+		}
 	}
-	defer helper.CommitOrRollback(tx, &err)
 
-	user.UpdatedAt = int32(time.Now().Unix())
 	query := "UPDATE users SET"
 	params := []interface{}{}
 	paramCount := 1
@@ -116,66 +129,172 @@ func (repository *UserRepositoryImpl) UserUpdate(ctx context.Context, user domai
 		paramCount++
 	}
 
-	query += ", updated_at = $" + strconv.Itoa(paramCount)
-	params = append(params, user.UpdatedAt)
-	paramCount++
-
-	query += " WHERE id = $" + strconv.Itoa(paramCount)
+	query += ", updated_at = now() WHERE id = $" + strconv.Itoa(paramCount) + " RETURNING updated_at"
 	params = append(params, user.Id)
 
-	_, err = tx.ExecContext(ctx, query, params...)
+	err = tx.QueryRowContext(ctx, query, params...).Scan(&user.UpdatedAt)
 	if err != nil {
+		err = userConflict(err)
 		return domain.User{}, err
 	}
 
-	return user, nil
+	// keep the old name so links to it can be redirected and it stays reserved for a while
+	if user.Username != "" && user.Username != oldUsername {
+		queryHistory := "INSERT INTO username_history (user_id, username, changed_at) VALUES ($1, $2, $3)"
+		_, err = tx.ExecContext(ctx, queryHistory, user.Id, oldUsername, user.UpdatedAt)
+		if err != nil {
+			return domain.User{}, err
+		}
+	}
+
+	return user, tx.Commit()
 }
 
 // UserDelete is a method to "soft delete" a user in the database by setting the deleted_at field.
 func (repository *UserRepositoryImpl) UserDelete(ctx context.Context, id uuid.UUID) error {
-	tx, err := repository.Database.Begin()
+	tx, err := helper.BeginTx(ctx, repository.Database, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := "UPDATE users SET deleted_at=now() WHERE id=$1 AND deleted_at IS NULL"
+	result, err := tx.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
 	if err != nil {
 		return err
 	}
-	defer helper.CommitOrRollback(tx, &err)
+	if rows == 0 {
+		err = ErrUserNotFound
+		return err
+	}
 
-	deleteTime := int32(time.Now().Unix())
+	return tx.Commit()
+}
+
+// SuspendUser is a method to suspend a user account and revoke all of its refresh tokens.
+func (repository *UserRepositoryImpl) SuspendUser(ctx context.Context, id uuid.UUID) error {
+	tx, err := helper.BeginTx(ctx, repository.Database, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
 
-	query := "UPDATE users SET deleted_at=$1 WHERE id=$2"
-	_, err = tx.ExecContext(ctx, query, deleteTime, id)
+	query := "UPDATE users SET suspended_at=now() WHERE id=$1 AND deleted_at IS NULL"
+	result, err := tx.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
 	if err != nil {
-		return errors.New(err.Error())
+		return err
+	}
+	if rows == 0 {
+		err = ErrUserNotFound
+		return err
 	}
 
-	return nil
+	queryRevoke := "UPDATE refresh_tokens SET revoked_at=now() WHERE user_id=$1 AND revoked_at IS NULL"
+	_, err = tx.ExecContext(ctx, queryRevoke, id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// UnsuspendUser is a method to lift the suspension of a user account.
+func (repository *UserRepositoryImpl) UnsuspendUser(ctx context.Context, id uuid.UUID) error {
+	tx, err := helper.BeginTx(ctx, repository.Database, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := "UPDATE users SET suspended_at=NULL WHERE id=$1 AND deleted_at IS NULL"
+	result, err := tx.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		err = ErrUserNotFound
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// UpdateUserRole is a method to change the role of a user.
+func (repository *UserRepositoryImpl) UpdateUserRole(ctx context.Context, id uuid.UUID, role string) error {
+	tx, err := helper.BeginTx(ctx, repository.Database, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := "UPDATE users SET role=$1 WHERE id=$2 AND deleted_at IS NULL"
+	result, err := tx.ExecContext(ctx, query, role, id)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		err = ErrUserNotFound
+		return err
+	}
+
+	return tx.Commit()
 }
 
 func (repository *UserRepositoryImpl) FindUser(ctx context.Context, id uuid.UUID) (user domain.User, err error) {
-	tx, err := repository.Database.Begin()
+	tx, err := helper.BeginTx(ctx, repository.Database, helper.ReadOnly)
 	if err != nil {
 		return
 	}
-	defer helper.CommitOrRollback(tx, &err)
+	defer tx.Rollback()
 
-	query := "SELECT username, name, profile_picture_base64 FROM users WHERE id=$1"
-	err = tx.QueryRowContext(ctx, query, id).Scan(&user.Username, &user.Name, &user.ProfilePicture)
+	query := "SELECT username, name, profile_picture_base64, role FROM users WHERE id=$1"
+	err = tx.QueryRowContext(ctx, query, id).Scan(&user.Username, &user.Name, &user.ProfilePicture, &user.Role)
+	if err == sql.ErrNoRows {
+		err = ErrUserNotFound
+		return
+	}
 	if err != nil {
 		return
 	}
 
-	return user, nil
+	return user, tx.Commit()
 
 }
 
-func (repository *UserRepositoryImpl) FindAllUser(ctx context.Context) (users []domain.User, err error) {
-	tx, err := repository.Database.Begin()
+// FindAllUser is a method to retrieve a page of users, ordered by username, leaving out the given user.
+func (repository *UserRepositoryImpl) FindAllUser(ctx context.Context, excludeId uuid.UUID, page domain.Page) (users []domain.User, next domain.Cursor, err error) {
+	tx, err := helper.BeginTx(ctx, repository.Database, helper.ReadOnly)
 	if err != nil {
 		return
 	}
-	defer helper.CommitOrRollback(tx, &err)
+	defer tx.Rollback()
+
+	query := "SELECT username, profile_picture_base64 FROM users WHERE deleted_at is NULL AND id <> $1"
+	params := []interface{}{excludeId}
+	if !page.Cursor.IsZero() {
+		query += " AND username > $2"
+		params = append(params, page.Cursor.Key)
+	}
+	query += " ORDER BY username LIMIT $" + strconv.Itoa(len(params)+1)
+	params = append(params, page.Limit+1)
 
-	query := "SELECT username, profile_picture_base64 FROM users WHERE deleted_at is NULL"
-	rows, err := tx.QueryContext(ctx, query)
+	rows, err := tx.QueryContext(ctx, query, params...)
 	if err != nil {
 		return
 	}
@@ -190,6 +309,236 @@ func (repository *UserRepositoryImpl) FindAllUser(ctx context.Context) (users []
 
 		users = append(users, user)
 	}
+	err = rows.Err()
+	if err != nil {
+		return
+	}
+
+	if len(users) > page.Limit {
+		users = users[:page.Limit]
+		next = domain.Cursor{Key: users[len(users)-1].Username}
+	}
+
+	err = tx.Commit()
+	return
+}
+
+// FindUserByUsername is a method to retrieve a user by username. A username the user went by
+// before also matches, as long as no current account has taken it.
+func (repository *UserRepositoryImpl) FindUserByUsername(ctx context.Context, username string) (user domain.User, err error) {
+	tx, err := helper.BeginTx(ctx, repository.Database, helper.ReadOnly)
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	query := "SELECT id, username, name, profile_picture_base64, follower_count, following_count FROM users WHERE username=$1 AND deleted_at IS NULL"
+	err = tx.QueryRowContext(ctx, query, username).Scan(&user.Id, &user.Username, &user.Name, &user.ProfilePicture, &user.FollowerCount, &user.FollowingCount)
+	if err == sql.ErrNoRows {
+		queryHistory := "SELECT users.id, users.username, users.name, users.profile_picture_base64, users.follower_count, users.following_count " +
+			"FROM username_history JOIN users ON users.id = username_history.user_id " +
+			"WHERE username_history.username=$1 AND users.deleted_at IS NULL ORDER BY username_history.changed_at DESC LIMIT 1"
+		err = tx.QueryRowContext(ctx, queryHistory, username).Scan(&user.Id, &user.Username, &user.Name, &user.ProfilePicture, &user.FollowerCount, &user.FollowingCount)
+	}
+	if err == sql.ErrNoRows {
+		err = ErrUserNotFound
+	}
+	if err != nil {
+		return
+	}
 
+	err = tx.Commit()
 	return
 }
+
+// UsernameReserved is a method to check whether another user gave up username after since.
+func (repository *UserRepositoryImpl) UsernameReserved(ctx context.Context, username string, userId uuid.UUID, since time.Time) (bool, error) {
+	tx, err := helper.BeginTx(ctx, repository.Database, helper.ReadOnly)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var reserved bool
+	query := "SELECT EXISTS (SELECT 1 FROM username_history WHERE username=$1 AND user_id <> $2 AND changed_at > $3)"
+	err = tx.QueryRowContext(ctx, query, username, userId, since).Scan(&reserved)
+	if err != nil {
+		return false, err
+	}
+
+	return reserved, tx.Commit()
+}
+
+// usernameLockClass is the first key of the postgres advisory locks held on usernames, the second
+// being a hash of the username.
+const usernameLockClass = 72_119_942
+
+// LockUsername is a method to keep other users from claiming or giving up username until the transaction it runs in
+// ends. It must run within a unit of work started by a TxManager, or the lock is released straight away.
+func (repository *UserRepositoryImpl) LockUsername(ctx context.Context, username string) error {
+	tx, err := helper.BeginTx(ctx, repository.Database, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = lockUsername(ctx, tx, username)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// lockUsername holds the advisory lock on username until tx ends, so a reservation check made
+// under it sees every change of that username committed before.
+func lockUsername(ctx context.Context, tx *helper.Tx, username string) error {
+	_, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1, hashtext($2))", usernameLockClass, username)
+	return err
+}
+
+// GetMentions is a method to retrieve, newest first, the photo captions and comments that mention a user,
+// with their authors. Those written by deleted or suspended users are left out.
+func (repository *UserRepositoryImpl) GetMentions(ctx context.Context, userId uuid.UUID, page domain.Page) ([]domain.Mention, []domain.User, domain.Cursor, error) {
+	tx, err := helper.BeginTx(ctx, repository.Database, helper.ReadOnly)
+	if err != nil {
+		return []domain.Mention{}, []domain.User{}, domain.Cursor{}, err
+	}
+	defer tx.Rollback()
+
+	// comment_id is 0 for captions, so (created_at, comment_id, photo_id) orders every mention
+	query := "SELECT mentions.photo_id, mentions.comment_id, mentions.text, mentions.user_id, mentions.created_at, users.email, users.username FROM (" +
+		"SELECT photos.id AS photo_id, 0 AS comment_id, photos.caption AS text, photos.user_id, photos.created_at FROM photos " +
+		"WHERE photos.deleted_at IS NULL AND photos.id IN (SELECT photo_id FROM photo_mentions WHERE user_id = $1) " +
+		"UNION ALL SELECT comments.photo_id, comments.id, comments.message, comments.user_id, comments.created_at FROM comments JOIN photos ON photos.id = comments.photo_id " +
+		"WHERE comments.deleted_at IS NULL AND photos.deleted_at IS NULL AND comments.id IN (SELECT comment_id FROM comment_mentions WHERE user_id = $1)" +
+		") mentions JOIN users ON users.id = mentions.user_id WHERE users.deleted_at IS NULL AND users.suspended_at IS NULL"
+	params := []interface{}{userId}
+	if !page.Cursor.IsZero() {
+		commentKey, photoKey, _ := strings.Cut(page.Cursor.Key, ":")
+		cursorCommentId, errComment := strconv.Atoi(commentKey)
+		cursorPhotoId, errPhoto := strconv.Atoi(photoKey)
+		if errComment != nil || errPhoto != nil {
+			return []domain.Mention{}, []domain.User{}, domain.Cursor{}, helper.ErrInvalidCursor
+		}
+		query += " AND (mentions.created_at, mentions.comment_id, mentions.photo_id) < ($2, $3, $4)"
+		params = append(params, page.Cursor.Timestamp(), cursorCommentId, cursorPhotoId)
+	}
+	query += " ORDER BY mentions.created_at DESC, mentions.comment_id DESC, mentions.photo_id DESC LIMIT $" + strconv.Itoa(len(params)+1)
+	params = append(params, page.Limit+1)
+
+	rows, err := tx.QueryContext(ctx, query, params...)
+	if err != nil {
+		return []domain.Mention{}, []domain.User{}, domain.Cursor{}, err
+	}
+	defer rows.Close()
+
+	mentions := []domain.Mention{}
+	users := []domain.User{}
+	for rows.Next() {
+		var mention domain.Mention
+		var user domain.User
+		err = rows.Scan(&mention.PhotoId, &mention.CommentId, &mention.Text, &mention.UserId, &mention.CreatedAt, &user.Email, &user.Username)
+		if err != nil {
+			return []domain.Mention{}, []domain.User{}, domain.Cursor{}, err
+		}
+		user.Id = mention.UserId
+		mentions = append(mentions, mention)
+		users = append(users, user)
+	}
+	err = rows.Err()
+	if err != nil {
+		return []domain.Mention{}, []domain.User{}, domain.Cursor{}, err
+	}
+
+	next := domain.Cursor{}
+	if len(mentions) > page.Limit {
+		mentions, users = mentions[:page.Limit], users[:page.Limit]
+		last := mentions[len(mentions)-1]
+		next = domain.NewTimeCursor(last.CreatedAt, strconv.Itoa(last.CommentId)+":"+strconv.Itoa(last.PhotoId))
+	}
+
+	var photoIds, commentIds []int
+	for _, mention := range mentions {
+		if mention.CommentId != 0 {
+			commentIds = append(commentIds, mention.CommentId)
+		} else {
+			photoIds = append(photoIds, mention.PhotoId)
+		}
+	}
+	photoEntities, err := loadEntities(ctx, tx, photoEntityTables, photoIds)
+	if err != nil {
+		return []domain.Mention{}, []domain.User{}, domain.Cursor{}, err
+	}
+	commentEntities, err := loadEntities(ctx, tx, commentEntityTables, commentIds)
+	if err != nil {
+		return []domain.Mention{}, []domain.User{}, domain.Cursor{}, err
+	}
+	for i, mention := range mentions {
+		if mention.CommentId != 0 {
+			mentions[i].Entities = commentEntities[mention.CommentId]
+		} else {
+			mentions[i].Entities = photoEntities[mention.PhotoId]
+		}
+	}
+
+	return mentions, users, next, tx.Commit()
+}
+
+// SearchUsers is a method to retrieve a page of the users whose username or name starts with the words
+// of query, or whose username looks like query, most relevant first.
+func (repository *UserRepositoryImpl) SearchUsers(ctx context.Context, query string, page domain.Page) ([]domain.User, domain.Cursor, error) {
+	tx, err := helper.BeginTx(ctx, repository.Database, helper.ReadOnly)
+	if err != nil {
+		return []domain.User{}, domain.Cursor{}, err
+	}
+	defer tx.Rollback()
+
+	querySearch := "SELECT id, username, name, profile_picture_base64, rank FROM (" +
+		"SELECT users.id, users.username, users.name, users.profile_picture_base64, " +
+		"(ts_rank(users.search_vector, to_tsquery('simple', $1)) + similarity(users.username, $2))::float8 AS rank " +
+		"FROM users WHERE users.deleted_at IS NULL AND users.suspended_at IS NULL AND (users.search_vector @@ to_tsquery('simple', $1) OR users.username % $2)" +
+		") results"
+	params := []interface{}{helper.PrefixQuery(query), query}
+	if !page.Cursor.IsZero() {
+		cursorId, errCursor := uuid.Parse(page.Cursor.Key)
+		if errCursor != nil {
+			return []domain.User{}, domain.Cursor{}, helper.ErrInvalidCursor
+		}
+		querySearch += " WHERE (rank, id) < ($3, $4)"
+		params = append(params, page.Cursor.Rank, cursorId)
+	}
+	querySearch += " ORDER BY rank DESC, id DESC LIMIT $" + strconv.Itoa(len(params)+1)
+	params = append(params, page.Limit+1)
+
+	rows, err := tx.QueryContext(ctx, querySearch, params...)
+	if err != nil {
+		return []domain.User{}, domain.Cursor{}, err
+	}
+	defer rows.Close()
+
+	users := []domain.User{}
+	var ranks []float64
+	for rows.Next() {
+		var user domain.User
+		var rank float64
+		err = rows.Scan(&user.Id, &user.Username, &user.Name, &user.ProfilePicture, &rank)
+		if err != nil {
+			return []domain.User{}, domain.Cursor{}, err
+		}
+		users = append(users, user)
+		ranks = append(ranks, rank)
+	}
+	err = rows.Err()
+	if err != nil {
+		return []domain.User{}, domain.Cursor{}, err
+	}
+
+	next := domain.Cursor{}
+	if len(users) > page.Limit {
+		users = users[:page.Limit]
+		next = domain.NewRankCursor(ranks[page.Limit-1], users[page.Limit-1].Id.String())
+	}
+
+	return users, next, tx.Commit()
+}
@@ -0,0 +1,28 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+)
+
+// PrivatePrefix holds objects that must never be served, such as photos as they were
+// uploaded, still carrying their EXIF metadata. URL returns "" for keys below it.
+const PrivatePrefix = "incoming/"
+
+var ErrObjectNotFound = errors.New("object not found")
+
+func IsPrivate(key string) bool {
+	return strings.HasPrefix(key, PrivatePrefix)
+}
+
+// BlobStore is the storage backend for binary objects such as uploaded photos.
+// Objects are addressed by a key like "photos/<uuid>.jpg" which is what gets
+// persisted in the database, never the object itself.
+type BlobStore interface {
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	URL(key string) string
+}
@@ -0,0 +1,105 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type LocalBlobStore struct {
+	Root    string
+	BaseURL string
+}
+
+func NewLocalBlobStore(root string, baseURL string) BlobStore {
+	return &LocalBlobStore{
+		Root:    root,
+		BaseURL: strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+// path resolves a key to a file below Root, refusing keys that would escape it.
+func (store *LocalBlobStore) path(key string) (string, error) {
+	cleaned := filepath.Clean("/" + key)
+	if cleaned == "/" {
+		return "", errors.New("invalid object key")
+	}
+	return filepath.Join(store.Root, filepath.FromSlash(cleaned)), nil
+}
+
+// Put writes the object to a temporary file first and renames it into place so
+// readers never observe a partially written object.
+func (store *LocalBlobStore) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	path, err := store.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = io.Copy(tmp, body)
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+	err = tmp.Close()
+	if err != nil {
+		return err
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+func (store *LocalBlobStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := store.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrObjectNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return file, nil
+}
+
+func (store *LocalBlobStore) Delete(ctx context.Context, key string) error {
+	path, err := store.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+func (store *LocalBlobStore) URL(key string) string {
+	if key == "" || IsPrivate(key) {
+		return ""
+	}
+	return store.BaseURL + "/" + key
+}
@@ -0,0 +1,237 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// unsignedPayload lets uploads stream without hashing the body up front.
+const unsignedPayload = "UNSIGNED-PAYLOAD"
+
+// S3BlobStore talks to any S3-compatible service (AWS S3, MinIO, ...) using
+// plain HTTP requests signed with AWS Signature Version 4.
+type S3BlobStore struct {
+	Endpoint  *url.URL
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	PathStyle bool
+	PublicURL string
+	Client    *http.Client
+}
+
+func NewS3BlobStore(endpoint string, region string, bucket string, accessKey string, secretKey string, pathStyle bool, publicURL string) (BlobStore, error) {
+	endpointURL, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if endpointURL.Scheme == "" || endpointURL.Host == "" {
+		return nil, fmt.Errorf("invalid s3 endpoint %q", endpoint)
+	}
+
+	return &S3BlobStore{
+		Endpoint:  endpointURL,
+		Region:    region,
+		Bucket:    bucket,
+		AccessKey: accessKey,
+		SecretKey: secretKey,
+		PathStyle: pathStyle,
+		PublicURL: strings.TrimSuffix(publicURL, "/"),
+		Client:    http.DefaultClient,
+	}, nil
+}
+
+func (store *S3BlobStore) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	req, err := store.newRequest(ctx, http.MethodPut, key, body)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := store.do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	return nil
+}
+
+func (store *S3BlobStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	req, err := store.newRequest(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := store.do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Body, nil
+}
+
+func (store *S3BlobStore) Delete(ctx context.Context, key string) error {
+	req, err := store.newRequest(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := store.do(req)
+	if err == ErrObjectNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	return nil
+}
+
+func (store *S3BlobStore) URL(key string) string {
+	if key == "" || IsPrivate(key) {
+		return ""
+	}
+	if store.PublicURL != "" {
+		return store.PublicURL + "/" + s3Escape(key)
+	}
+	return store.objectURL(key).String()
+}
+
+// objectURL builds the address of an object using either path-style
+// (endpoint/bucket/key, what MinIO expects) or virtual-hosted-style
+// (bucket.endpoint/key) addressing.
+func (store *S3BlobStore) objectURL(key string) *url.URL {
+	objectURL := *store.Endpoint
+	basePath := strings.TrimSuffix(objectURL.Path, "/")
+	if store.PathStyle {
+		objectURL.Path = basePath + "/" + store.Bucket + "/" + key
+		objectURL.RawPath = basePath + "/" + s3Escape(store.Bucket) + "/" + s3Escape(key)
+	} else {
+		objectURL.Host = store.Bucket + "." + objectURL.Host
+		objectURL.Path = basePath + "/" + key
+		objectURL.RawPath = basePath + "/" + s3Escape(key)
+	}
+	return &objectURL
+}
+
+func (store *S3BlobStore) newRequest(ctx context.Context, method string, key string, body io.Reader) (*http.Request, error) {
+	if key == "" {
+		return nil, fmt.Errorf("invalid object key")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, store.objectURL(key).String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+func (store *S3BlobStore) do(req *http.Request) (*http.Response, error) {
+	store.sign(req, time.Now())
+
+	resp, err := store.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, ErrObjectNotFound
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		resp.Body.Close()
+		return nil, fmt.Errorf("s3 %s %s: %s: %s", req.Method, req.URL.Path, resp.Status, strings.TrimSpace(string(message)))
+	}
+
+	return resp, nil
+}
+
+// sign adds an AWS Signature Version 4 Authorization header to req.
+func (store *S3BlobStore) sign(req *http.Request, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", unsignedPayload)
+
+	headers := map[string]string{
+		"host":                 req.URL.Host,
+		"x-amz-date":           amzDate,
+		"x-amz-content-sha256": unsignedPayload,
+	}
+	if contentType := req.Header.Get("Content-Type"); contentType != "" {
+		headers["content-type"] = contentType
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.Query().Encode(),
+		canonicalHeaders.String(),
+		signedHeaders,
+		unsignedPayload,
+	}, "\n")
+
+	scope := date + "/" + store.Region + "/s3/aws4_request"
+	canonicalHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(canonicalHash[:])
+
+	signingKey := hmacSHA256([]byte("AWS4"+store.SecretKey), date)
+	signingKey = hmacSHA256(signingKey, store.Region)
+	signingKey = hmacSHA256(signingKey, "s3")
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", store.AccessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// s3Escape percent-encodes a key the way SigV4 expects: everything except
+// unreserved characters, keeping "/" as the segment separator.
+func s3Escape(key string) string {
+	var escaped strings.Builder
+	for _, b := range []byte(key) {
+		switch {
+		case 'A' <= b && b <= 'Z', 'a' <= b && b <= 'z', '0' <= b && b <= '9',
+			b == '-', b == '_', b == '.', b == '~', b == '/':
+			escaped.WriteByte(b)
+		default:
+			fmt.Fprintf(&escaped, "%%%02X", b)
+		}
+	}
+	return escaped.String()
+}
@@ -9,7 +9,11 @@ import (
 
 type CommentUsecase interface {
 	PostComment(ctx context.Context, request request.Comment) (response.PostComment, error)
-	GetComment(ctx context.Context) ([]response.GetComment, error)
+	GetComment(ctx context.Context, request request.Page) ([]response.GetComment, string, error)
+	GetPhotoComments(ctx context.Context, photoId int, request request.Page) ([]response.ThreadComment, string, error)
+	GetReplies(ctx context.Context, commentId int, request request.Page) ([]response.ThreadComment, string, error)
 	UpdateComment(ctx context.Context, request request.Comment) (response.UpdateComment, error)
 	DeleteComment(ctx context.Context, id int) error
+	PinComment(ctx context.Context, id int) error
+	UnpinComment(ctx context.Context, id int) error
 }
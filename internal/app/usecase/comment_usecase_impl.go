@@ -4,7 +4,11 @@ import (
 	"context"
 	"time"
 
+	"github.com/dihanto/gosnap/internal/app/auth"
+	"github.com/dihanto/gosnap/internal/app/helper"
+	"github.com/dihanto/gosnap/internal/app/imaging"
 	"github.com/dihanto/gosnap/internal/app/repository"
+	"github.com/dihanto/gosnap/internal/app/storage"
 	"github.com/dihanto/gosnap/model/domain"
 	"github.com/dihanto/gosnap/model/web/request"
 	"github.com/dihanto/gosnap/model/web/response"
@@ -12,16 +16,31 @@ import (
 )
 
 type CommentUsecaseImpl struct {
-	Repository repository.CommentRepository
-	Validate   *validator.Validate
-	Timeout    int
+	Repository      repository.CommentRepository
+	PhotoRepository repository.PhotoRepository
+	Store           storage.BlobStore
+	Notifier        Notifier
+	TxManager       *helper.TxManager
+	Validate        *validator.Validate
+	Timeout         int
+	// MaxDepth is how deeply replies can be nested: 1 allows replies to comments
+	// but not to other replies, 0 turns replies off.
+	MaxDepth int
+	// MaxPinned is how many comments a photo owner can pin to the top of the photo's comments.
+	MaxPinned int
 }
 
-func NewCommentUsecase(repository repository.CommentRepository, validate *validator.Validate, timeout int) CommentUsecase {
+func NewCommentUsecase(repository repository.CommentRepository, photoRepository repository.PhotoRepository, store storage.BlobStore, notifier Notifier, txManager *helper.TxManager, validate *validator.Validate, timeout int, maxDepth int, maxPinned int) CommentUsecase {
 	return &CommentUsecaseImpl{
-		Repository: repository,
-		Validate:   validate,
-		Timeout:    timeout,
+		Repository:      repository,
+		PhotoRepository: photoRepository,
+		Store:           store,
+		Notifier:        notifier,
+		TxManager:       txManager,
+		Validate:        validate,
+		Timeout:         timeout,
+		MaxDepth:        maxDepth,
+		MaxPinned:       maxPinned,
 	}
 }
 func (usecase *CommentUsecaseImpl) PostComment(ctx context.Context, request request.Comment) (response.PostComment, error) {
@@ -34,34 +53,71 @@ func (usecase *CommentUsecaseImpl) PostComment(ctx context.Context, request requ
 	}
 
 	comment := domain.Comment{
-		Message: request.Message,
-		PhotoId: request.PhotoId,
-		UserId:  request.UserId,
+		Message:  request.Message,
+		Entities: helper.ParseEntities(request.Message),
+		PhotoId:  request.PhotoId,
+		ParentId: request.ParentId,
+		UserId:   request.UserId,
 	}
 
-	comment, err = usecase.Repository.PostComment(ctx, comment)
+	err = usecase.TxManager.WithinTx(ctx, func(ctx context.Context) error {
+		if comment.ParentId != 0 {
+			// a reply belongs to the photo of the comment it answers
+			parent, err := usecase.Repository.GetCommentById(ctx, comment.ParentId)
+			if err != nil {
+				return err
+			}
+			if parent.Depth >= usecase.MaxDepth {
+				return repository.ErrReplyTooDeep
+			}
+			comment.PhotoId = parent.PhotoId
+			comment.Depth = parent.Depth + 1
+		}
+
+		comment, err = usecase.Repository.PostComment(ctx, comment)
+		return err
+	})
 	if err != nil {
 		return response.PostComment{}, err
 	}
 
+	usecase.Notifier.Notify(ctx, domain.Notification{
+		Type:    domain.NotificationComment,
+		PhotoId: comment.PhotoId,
+		ActorId: comment.UserId,
+	})
+	notifyMentions(ctx, usecase.Notifier, comment.Entities, comment.PhotoId, comment.Id, comment.UserId)
+
 	commentResponse := response.PostComment{
 		Id:        comment.Id,
 		Message:   comment.Message,
+		Entities:  getEntityResponses(comment.Entities),
 		PhotoId:   comment.PhotoId,
+		ParentId:  comment.ParentId,
 		UserId:    comment.UserId,
-		CreatedAt: time.Unix(int64(comment.CreatedAt), 0),
+		CreatedAt: comment.CreatedAt,
 	}
 
 	return commentResponse, nil
 }
 
-func (usecase *CommentUsecaseImpl) GetComment(ctx context.Context) ([]response.GetComment, error) {
+func (usecase *CommentUsecaseImpl) GetComment(ctx context.Context, request request.Page) ([]response.GetComment, string, error) {
 	ctx, cancel := context.WithTimeout(ctx, time.Duration(usecase.Timeout)*time.Second)
 	defer cancel()
 
-	comments, users, photos, err := usecase.Repository.GetComment(ctx)
+	err := usecase.Validate.Struct(request)
+	if err != nil {
+		return nil, "", err
+	}
+
+	page, err := helper.NewPage(request, helper.DefaultPageLimit)
 	if err != nil {
-		return nil, err
+		return nil, "", err
+	}
+
+	comments, users, photos, next, err := usecase.Repository.GetComment(ctx, page)
+	if err != nil {
+		return nil, "", err
 	}
 
 	var commentsResponse []response.GetComment
@@ -81,11 +137,11 @@ func (usecase *CommentUsecaseImpl) GetComment(ctx context.Context) ([]response.G
 		for _, photo := range photos {
 			if photo.Id == comment.PhotoId {
 				photoResponse = response.PhotoComment{
-					Id:          photo.Id,
-					Title:       photo.Title,
-					Caption:     photo.Caption,
-					PhotoBase64: photo.PhotoBase64,
-					UserId:      photo.UserId,
+					Id:       photo.Id,
+					Title:    photo.Title,
+					Caption:  photo.Caption,
+					PhotoUrl: photoURL(usecase.Store, photo, imaging.Original),
+					UserId:   photo.UserId,
 				}
 			}
 		}
@@ -93,17 +149,63 @@ func (usecase *CommentUsecaseImpl) GetComment(ctx context.Context) ([]response.G
 		commentResponse := response.GetComment{
 			Id:        comment.Id,
 			Message:   comment.Message,
+			Entities:  getEntityResponses(comment.Entities),
 			PhotoId:   comment.PhotoId,
+			ParentId:  comment.ParentId,
 			UserId:    comment.UserId,
-			UpdatedAt: time.Unix(int64(comment.UpdatedAt), 0),
-			CreatedAt: time.Unix(int64(comment.CreatedAt), 0),
+			UpdatedAt: comment.UpdatedAt,
+			CreatedAt: comment.CreatedAt,
 			User:      userResponse,
 			Photo:     photoResponse,
 		}
 		commentsResponse = append(commentsResponse, commentResponse)
 	}
 
-	return commentsResponse, nil
+	return commentsResponse, helper.EncodeCursor(next), nil
+}
+
+func (usecase *CommentUsecaseImpl) GetPhotoComments(ctx context.Context, photoId int, request request.Page) ([]response.ThreadComment, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(usecase.Timeout)*time.Second)
+	defer cancel()
+
+	err := usecase.Validate.Struct(request)
+	if err != nil {
+		return nil, "", err
+	}
+
+	page, err := helper.NewPage(request, helper.DefaultPageLimit)
+	if err != nil {
+		return nil, "", err
+	}
+
+	comments, users, next, err := usecase.Repository.GetPhotoComments(ctx, photoId, page)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return getThreadResponses(comments, users), helper.EncodeCursor(next), nil
+}
+
+func (usecase *CommentUsecaseImpl) GetReplies(ctx context.Context, commentId int, request request.Page) ([]response.ThreadComment, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(usecase.Timeout)*time.Second)
+	defer cancel()
+
+	err := usecase.Validate.Struct(request)
+	if err != nil {
+		return nil, "", err
+	}
+
+	page, err := helper.NewPage(request, helper.DefaultPageLimit)
+	if err != nil {
+		return nil, "", err
+	}
+
+	comments, users, next, err := usecase.Repository.GetReplies(ctx, commentId, page)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return getThreadResponses(comments, users), helper.EncodeCursor(next), nil
 }
 
 func (usecase *CommentUsecaseImpl) UpdateComment(ctx context.Context, request request.Comment) (response.UpdateComment, error) {
@@ -116,12 +218,25 @@ func (usecase *CommentUsecaseImpl) UpdateComment(ctx context.Context, request re
 	}
 
 	comment := domain.Comment{
-		Id:      request.Id,
-		Message: request.Message,
-		UserId:  request.UserId,
+		Id:       request.Id,
+		Message:  request.Message,
+		Entities: helper.ParseEntities(request.Message),
+		UserId:   request.UserId,
 	}
 
-	comment, err = usecase.Repository.UpdateComment(ctx, comment)
+	err = usecase.TxManager.WithinTx(ctx, func(ctx context.Context) error {
+		ownerId, err := usecase.Repository.GetCommentOwner(ctx, request.Id)
+		if err != nil {
+			return err
+		}
+		err = auth.AuthorizeOwner(ctx, ownerId)
+		if err != nil {
+			return err
+		}
+
+		comment, err = usecase.Repository.UpdateComment(ctx, comment)
+		return err
+	})
 	if err != nil {
 		return response.UpdateComment{}, err
 	}
@@ -129,9 +244,10 @@ func (usecase *CommentUsecaseImpl) UpdateComment(ctx context.Context, request re
 	commentResponse := response.UpdateComment{
 		Id:        comment.Id,
 		Message:   comment.Message,
+		Entities:  getEntityResponses(comment.Entities),
 		PhotoId:   comment.PhotoId,
 		UserId:    comment.UserId,
-		UpdatedAt: time.Unix(int64(comment.UpdatedAt), 0),
+		UpdatedAt: comment.UpdatedAt,
 	}
 
 	return commentResponse, nil
@@ -141,10 +257,96 @@ func (usecase *CommentUsecaseImpl) DeleteComment(ctx context.Context, id int) er
 	ctx, cancel := context.WithTimeout(ctx, time.Duration(usecase.Timeout)*time.Second)
 	defer cancel()
 
-	err := usecase.Repository.DeleteComment(ctx, id)
+	return usecase.TxManager.WithinTx(ctx, func(ctx context.Context) error {
+		ownerId, err := usecase.Repository.GetCommentOwner(ctx, id)
+		if err != nil {
+			return err
+		}
+		err = auth.AuthorizeOwnerOrModerator(ctx, ownerId)
+		if err != nil {
+			return err
+		}
+
+		return usecase.Repository.DeleteComment(ctx, id)
+	})
+}
+
+// PinComment pins a top-level comment to the top of its photo's comments. Only the
+// owner of the photo can pin or unpin its comments.
+func (usecase *CommentUsecaseImpl) PinComment(ctx context.Context, id int) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(usecase.Timeout)*time.Second)
+	defer cancel()
+
+	return usecase.TxManager.WithinTx(ctx, func(ctx context.Context) error {
+		comment, err := usecase.Repository.GetCommentById(ctx, id)
+		if err != nil {
+			return err
+		}
+		if comment.ParentId != 0 {
+			return repository.ErrPinReply
+		}
+		err = usecase.authorizePhotoOwner(ctx, comment.PhotoId)
+		if err != nil {
+			return err
+		}
+
+		return usecase.Repository.PinComment(ctx, id, comment.PhotoId, usecase.MaxPinned)
+	})
+}
+
+func (usecase *CommentUsecaseImpl) UnpinComment(ctx context.Context, id int) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(usecase.Timeout)*time.Second)
+	defer cancel()
+
+	return usecase.TxManager.WithinTx(ctx, func(ctx context.Context) error {
+		comment, err := usecase.Repository.GetCommentById(ctx, id)
+		if err != nil {
+			return err
+		}
+		err = usecase.authorizePhotoOwner(ctx, comment.PhotoId)
+		if err != nil {
+			return err
+		}
+
+		return usecase.Repository.UnpinComment(ctx, id)
+	})
+}
+
+// authorizePhotoOwner lets only the owner of the photo through, since pinning is the owner's choice.
+func (usecase *CommentUsecaseImpl) authorizePhotoOwner(ctx context.Context, photoId int) error {
+	ownerId, err := usecase.PhotoRepository.GetPhotoOwner(ctx, photoId)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return auth.AuthorizeOwner(ctx, ownerId)
+}
+
+// getThreadResponses pairs comments with their authors, which the repository returns in the same order.
+func getThreadResponses(comments []domain.Comment, users []domain.User) []response.ThreadComment {
+	threadResponses := []response.ThreadComment{}
+	for i, comment := range comments {
+		user := users[i]
+		threadResponses = append(threadResponses, response.ThreadComment{
+			Id:         comment.Id,
+			Message:    comment.Message,
+			Entities:   getEntityResponses(comment.Entities),
+			UserId:     comment.UserId,
+			PhotoId:    comment.PhotoId,
+			ParentId:   comment.ParentId,
+			Depth:      comment.Depth,
+			ReplyCount: comment.ReplyCount,
+			LikeCount:  comment.LikeCount,
+			Pinned:     comment.PinnedAt != nil,
+			CreatedAt:  comment.CreatedAt,
+			UpdatedAt:  comment.UpdatedAt,
+			User: response.UserComment{
+				Id:       user.Id,
+				Email:    user.Email,
+				Username: user.Username,
+			},
+		})
+	}
+
+	return threadResponses
 }
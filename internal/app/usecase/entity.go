@@ -0,0 +1,45 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/dihanto/gosnap/model/domain"
+	"github.com/dihanto/gosnap/model/web/response"
+	"github.com/google/uuid"
+)
+
+func getEntityResponses(entities []domain.Entity) []response.Entity {
+	entityResponses := []response.Entity{}
+	for _, entity := range entities {
+		entityResponse := response.Entity{
+			Type:  entity.Type,
+			Start: entity.Start,
+			End:   entity.End,
+			Tag:   entity.Tag,
+		}
+		if entity.Type == domain.EntityMention {
+			userId := entity.UserId
+			entityResponse.Username = entity.Username
+			entityResponse.UserId = &userId
+		}
+		entityResponses = append(entityResponses, entityResponse)
+	}
+
+	return entityResponses
+}
+
+// notifyMentions notifies the users mentioned in a new photo caption or comment message.
+func notifyMentions(ctx context.Context, notifier Notifier, entities []domain.Entity, photoId int, commentId int, actorId uuid.UUID) {
+	for _, entity := range entities {
+		if entity.Type != domain.EntityMention {
+			continue
+		}
+		notifier.Notify(ctx, domain.Notification{
+			Type:      domain.NotificationMention,
+			UserId:    entity.UserId,
+			PhotoId:   photoId,
+			CommentId: commentId,
+			ActorId:   actorId,
+		})
+	}
+}
@@ -5,11 +5,12 @@ import (
 
 	"github.com/dihanto/gosnap/model/web/request"
 	"github.com/dihanto/gosnap/model/web/response"
+	"github.com/google/uuid"
 )
 
 type FollowUsecase interface {
 	FollowUser(ctx context.Context, request request.Follow) (response.Follow, error)
 	UnFollowUser(ctx context.Context, request request.Follow) (response.Follow, error)
-	GetFollower(ctx context.Context, request request.Follow) (followers response.GetFollower, err error)
-	GetFollowing(ctx context.Context, request request.Follow) (follows response.GetFollowing, err error)
+	GetFollower(ctx context.Context, userId uuid.UUID, pageRequest request.Page) (followers response.GetFollower, nextCursor string, err error)
+	GetFollowing(ctx context.Context, userId uuid.UUID, pageRequest request.Page) (follows response.GetFollowing, nextCursor string, err error)
 }
@@ -4,22 +4,26 @@ import (
 	"context"
 	"time"
 
+	"github.com/dihanto/gosnap/internal/app/helper"
 	"github.com/dihanto/gosnap/internal/app/repository"
 	"github.com/dihanto/gosnap/model/domain"
 	"github.com/dihanto/gosnap/model/web/request"
 	"github.com/dihanto/gosnap/model/web/response"
 	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
 )
 
 type FollowUsecaseImpl struct {
 	Repository repository.FollowRepository
+	Notifier   Notifier
 	Validate   *validator.Validate
 	Timeout    int
 }
 
-func NewFollowUsecaseImpl(repository repository.FollowRepository, validate *validator.Validate, timeout int) FollowUsecase {
+func NewFollowUsecaseImpl(repository repository.FollowRepository, notifier Notifier, validate *validator.Validate, timeout int) FollowUsecase {
 	return &FollowUsecaseImpl{
 		Repository: repository,
+		Notifier:   notifier,
 		Validate:   validate,
 		Timeout:    timeout,
 	}
@@ -33,14 +37,10 @@ func (usecase *FollowUsecaseImpl) FollowUser(ctx context.Context, request reques
 	if err != nil {
 		return response.Follow{}, err
 	}
-	err = usecase.Validate.Var(request.FollowerUsername, "follow="+request.TargetUsername)
-	if err != nil {
-		return response.Follow{}, err
-	}
 
 	followRequest := domain.Follow{
-		FollowerUsername: request.FollowerUsername,
-		TargetUsername:   request.TargetUsername,
+		FollowerId:     request.FollowerId,
+		TargetUsername: request.TargetUsername,
 	}
 
 	follow, err := usecase.Repository.FollowUser(ctx, followRequest)
@@ -48,6 +48,12 @@ func (usecase *FollowUsecaseImpl) FollowUser(ctx context.Context, request reques
 		return response.Follow{}, err
 	}
 
+	usecase.Notifier.Notify(ctx, domain.Notification{
+		Type:    domain.NotificationFollow,
+		UserId:  follow.FolloweeId,
+		ActorId: follow.FollowerId,
+	})
+
 	followResponse := response.Follow{
 		FollowerCount: follow.FollowerCount,
 	}
@@ -65,8 +71,8 @@ func (usecase *FollowUsecaseImpl) UnFollowUser(ctx context.Context, request requ
 	}
 
 	followRequest := domain.Follow{
-		FollowerUsername: request.FollowerUsername,
-		TargetUsername:   request.TargetUsername,
+		FollowerId:     request.FollowerId,
+		TargetUsername: request.TargetUsername,
 	}
 
 	follow, err := usecase.Repository.UnFollowUser(ctx, followRequest)
@@ -74,6 +80,12 @@ func (usecase *FollowUsecaseImpl) UnFollowUser(ctx context.Context, request requ
 		return response.Follow{}, err
 	}
 
+	usecase.Notifier.Retract(ctx, domain.Notification{
+		Type:    domain.NotificationFollow,
+		UserId:  follow.FolloweeId,
+		ActorId: request.FollowerId,
+	})
+
 	followResponse := response.Follow{
 		FollowerCount: follow.FollowerCount,
 	}
@@ -82,19 +94,24 @@ func (usecase *FollowUsecaseImpl) UnFollowUser(ctx context.Context, request requ
 
 }
 
-func (usecase *FollowUsecaseImpl) GetFollower(ctx context.Context, request request.Follow) (followers response.GetFollower, err error) {
+func (usecase *FollowUsecaseImpl) GetFollower(ctx context.Context, userId uuid.UUID, pageRequest request.Page) (followers response.GetFollower, nextCursor string, err error) {
 	ctx, cancel := context.WithTimeout(ctx, time.Duration(usecase.Timeout)*time.Second)
 	defer cancel()
 
-	err = usecase.Validate.Var(request.TargetUsername, "required")
+	err = usecase.Validate.Var(userId, "required")
 	if err != nil {
 		return
 	}
-	followersRequest := domain.Follow{
-		TargetUsername: request.TargetUsername,
+	err = usecase.Validate.Struct(pageRequest)
+	if err != nil {
+		return
+	}
+	page, err := helper.NewPage(pageRequest, helper.DefaultPageLimit)
+	if err != nil {
+		return
 	}
 
-	followersResponse, err := usecase.Repository.GetFollower(ctx, followersRequest)
+	followersResponse, count, next, err := usecase.Repository.GetFollower(ctx, userId, page)
 	if err != nil {
 		return
 	}
@@ -102,25 +119,30 @@ func (usecase *FollowUsecaseImpl) GetFollower(ctx context.Context, request reque
 	for _, followerResponse := range followersResponse {
 		followers.Username = append(followers.Username, followerResponse.Username)
 	}
-	followers.FollowerCount = len(followers.Username)
+	followers.FollowerCount = count
+	nextCursor = helper.EncodeCursor(next)
 
 	return
 }
 
-func (usecase *FollowUsecaseImpl) GetFollowing(ctx context.Context, request request.Follow) (follows response.GetFollowing, err error) {
+func (usecase *FollowUsecaseImpl) GetFollowing(ctx context.Context, userId uuid.UUID, pageRequest request.Page) (follows response.GetFollowing, nextCursor string, err error) {
 	ctx, cancel := context.WithTimeout(ctx, time.Duration(usecase.Timeout)*time.Second)
 	defer cancel()
 
-	err = usecase.Validate.Var(request.TargetUsername, "required")
+	err = usecase.Validate.Var(userId, "required")
 	if err != nil {
 		return
 	}
-
-	followRequest := domain.Follow{
-		TargetUsername: request.TargetUsername,
+	err = usecase.Validate.Struct(pageRequest)
+	if err != nil {
+		return
+	}
+	page, err := helper.NewPage(pageRequest, helper.DefaultPageLimit)
+	if err != nil {
+		return
 	}
 
-	followResponses, err := usecase.Repository.GetFollowing(ctx, followRequest)
+	followResponses, count, next, err := usecase.Repository.GetFollowing(ctx, userId, page)
 	if err != nil {
 		return
 	}
@@ -128,7 +150,8 @@ func (usecase *FollowUsecaseImpl) GetFollowing(ctx context.Context, request requ
 	for _, followResponse := range followResponses {
 		follows.Username = append(follows.Username, followResponse.Username)
 	}
-	follows.FollowingCount = len(follows.Username)
+	follows.FollowingCount = count
+	nextCursor = helper.EncodeCursor(next)
 
 	return
 }
@@ -11,4 +11,6 @@ type LikeUsecase interface {
 	LikePhoto(ctx context.Context, request request.Like) (response.Like, error)
 	UnlikePhoto(ctx context.Context, request request.Like) (response.Unlike, error)
 	IsLikePhoto(ctx context.Context, request request.Like) (bool, error)
+	LikeComment(ctx context.Context, request request.CommentLike) (response.CommentLike, error)
+	UnlikeComment(ctx context.Context, request request.CommentLike) (response.CommentUnlike, error)
 }
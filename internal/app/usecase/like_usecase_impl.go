@@ -2,7 +2,6 @@ package usecase
 
 import (
 	"context"
-	"strconv"
 	"time"
 
 	"github.com/dihanto/gosnap/internal/app/repository"
@@ -14,13 +13,15 @@ import (
 
 type LikeUsecaseImpl struct {
 	Repository repository.LikeRepository
+	Notifier   Notifier
 	Validate   *validator.Validate
 	Timeout    int
 }
 
-func NewLikeUsecaseImpl(repository repository.LikeRepository, validate *validator.Validate, timeout int) LikeUsecase {
+func NewLikeUsecaseImpl(repository repository.LikeRepository, notifier Notifier, validate *validator.Validate, timeout int) LikeUsecase {
 	return &LikeUsecaseImpl{
 		Repository: repository,
+		Notifier:   notifier,
 		Validate:   validate,
 		Timeout:    timeout,
 	}
@@ -34,16 +35,10 @@ func (usecase *LikeUsecaseImpl) LikePhoto(ctx context.Context, request request.L
 	if err != nil {
 		return response.Like{}, err
 	}
-	photoIdString := strconv.Itoa(request.PhotoId)
-	err = usecase.Validate.Var(request.UserId, "likes="+photoIdString)
-	if err != nil {
-		return response.Like{}, err
-	}
 
 	likeRequest := domain.Like{
-		PhotoId:   request.PhotoId,
-		UserId:    request.UserId,
-		CreatedAt: int32(time.Now().Unix()),
+		PhotoId: request.PhotoId,
+		UserId:  request.UserId,
 	}
 
 	like, err := usecase.Repository.LikePhoto(ctx, likeRequest)
@@ -51,11 +46,17 @@ func (usecase *LikeUsecaseImpl) LikePhoto(ctx context.Context, request request.L
 		return response.Like{}, err
 	}
 
+	usecase.Notifier.Notify(ctx, domain.Notification{
+		Type:    domain.NotificationLike,
+		PhotoId: like.PhotoId,
+		ActorId: like.UserId,
+	})
+
 	likeResponse := response.Like{
 		PhotoId:   like.PhotoId,
 		UserId:    like.UserId,
 		LikeCount: like.LikeCount,
-		LikedAt:   time.Now(),
+		LikedAt:   like.CreatedAt,
 	}
 
 	return likeResponse, nil
@@ -80,6 +81,12 @@ func (usecase *LikeUsecaseImpl) UnlikePhoto(ctx context.Context, request request
 		return response.Unlike{}, err
 	}
 
+	usecase.Notifier.Retract(ctx, domain.Notification{
+		Type:    domain.NotificationLike,
+		PhotoId: request.PhotoId,
+		ActorId: request.UserId,
+	})
+
 	likeResponse := response.Unlike{
 		LikeCount: like.LikeCount,
 		PhotoId:   like.PhotoId,
@@ -97,15 +104,61 @@ func (usecase *LikeUsecaseImpl) IsLikePhoto(ctx context.Context, request request
 		return false, err
 	}
 
-	userIds, err := usecase.Repository.IsLikePhoto(ctx, request.PhotoId)
+	return usecase.Repository.IsLikePhoto(ctx, request.PhotoId, request.UserId)
+}
+
+func (usecase *LikeUsecaseImpl) LikeComment(ctx context.Context, request request.CommentLike) (response.CommentLike, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(usecase.Timeout)*time.Second)
+	defer cancel()
+
+	err := usecase.Validate.Struct(request)
 	if err != nil {
-		return false, err
+		return response.CommentLike{}, err
 	}
 
-	for _, userId := range userIds {
-		if userId == request.UserId {
-			return true, nil
-		}
+	likeRequest := domain.CommentLike{
+		CommentId: request.CommentId,
+		UserId:    request.UserId,
 	}
-	return false, nil
+
+	like, err := usecase.Repository.LikeComment(ctx, likeRequest)
+	if err != nil {
+		return response.CommentLike{}, err
+	}
+
+	likeResponse := response.CommentLike{
+		CommentId: like.CommentId,
+		UserId:    like.UserId,
+		LikeCount: like.LikeCount,
+		LikedAt:   like.CreatedAt,
+	}
+
+	return likeResponse, nil
+}
+
+func (usecase *LikeUsecaseImpl) UnlikeComment(ctx context.Context, request request.CommentLike) (response.CommentUnlike, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(usecase.Timeout)*time.Second)
+	defer cancel()
+
+	err := usecase.Validate.Struct(request)
+	if err != nil {
+		return response.CommentUnlike{}, err
+	}
+
+	likeRequest := domain.CommentLike{
+		CommentId: request.CommentId,
+		UserId:    request.UserId,
+	}
+
+	like, err := usecase.Repository.UnlikeComment(ctx, likeRequest)
+	if err != nil {
+		return response.CommentUnlike{}, err
+	}
+
+	likeResponse := response.CommentUnlike{
+		LikeCount: like.LikeCount,
+		CommentId: like.CommentId,
+	}
+
+	return likeResponse, nil
 }
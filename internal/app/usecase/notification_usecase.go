@@ -0,0 +1,25 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/dihanto/gosnap/model/domain"
+	"github.com/dihanto/gosnap/model/web/request"
+	"github.com/dihanto/gosnap/model/web/response"
+	"github.com/google/uuid"
+)
+
+// Notifier records the events users are notified about, and takes them back when they are
+// undone. Both are best effort: a failure is logged and never fails the action behind the event.
+type Notifier interface {
+	Notify(ctx context.Context, notification domain.Notification)
+	Retract(ctx context.Context, notification domain.Notification)
+}
+
+type NotificationUsecase interface {
+	Notifier
+	GetNotifications(ctx context.Context, request request.Notifications) (response.Notifications, string, error)
+	MarkRead(ctx context.Context, request request.NotificationRead) error
+	GetPreferences(ctx context.Context, userId uuid.UUID) (response.NotificationPreferences, error)
+	UpdatePreferences(ctx context.Context, request request.NotificationPreferences) (response.NotificationPreferences, error)
+}
@@ -0,0 +1,237 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/dihanto/gosnap/internal/app/helper"
+	"github.com/dihanto/gosnap/internal/app/repository"
+	"github.com/dihanto/gosnap/model/domain"
+	"github.com/dihanto/gosnap/model/web/request"
+	"github.com/dihanto/gosnap/model/web/response"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+type NotificationUsecaseImpl struct {
+	Repository      repository.NotificationRepository
+	PhotoRepository repository.PhotoRepository
+	TxManager       *helper.TxManager
+	Validate        *validator.Validate
+	Timeout         int
+}
+
+func NewNotificationUsecase(repository repository.NotificationRepository, photoRepository repository.PhotoRepository, txManager *helper.TxManager, validate *validator.Validate, timeout int) NotificationUsecase {
+	return &NotificationUsecaseImpl{
+		Repository:      repository,
+		PhotoRepository: photoRepository,
+		TxManager:       txManager,
+		Validate:        validate,
+		Timeout:         timeout,
+	}
+}
+
+// Notify records notification for its UserId or, when that isn't set, for the owner of its photo.
+func (usecase *NotificationUsecaseImpl) Notify(ctx context.Context, notification domain.Notification) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(usecase.Timeout)*time.Second)
+	defer cancel()
+
+	notification, ok := usecase.addressee(ctx, notification)
+	if !ok {
+		return
+	}
+
+	err := usecase.Repository.Record(ctx, notification)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// Retract takes the actor of notification back out of the unread notification Notify
+// recorded it in, as when a like is withdrawn.
+func (usecase *NotificationUsecaseImpl) Retract(ctx context.Context, notification domain.Notification) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(usecase.Timeout)*time.Second)
+	defer cancel()
+
+	notification, ok := usecase.addressee(ctx, notification)
+	if !ok {
+		return
+	}
+
+	err := usecase.Repository.Retract(ctx, notification)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+func (usecase *NotificationUsecaseImpl) GetNotifications(ctx context.Context, request request.Notifications) (response.Notifications, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(usecase.Timeout)*time.Second)
+	defer cancel()
+
+	err := usecase.Validate.Struct(request)
+	if err != nil {
+		return response.Notifications{}, "", err
+	}
+
+	page, err := helper.NewPage(request.Page, helper.DefaultPageLimit)
+	if err != nil {
+		return response.Notifications{}, "", err
+	}
+
+	var notifications []domain.Notification
+	var actors []domain.User
+	var next domain.Cursor
+	var unreadCount int
+	err = usecase.TxManager.WithinReadOnlyTx(ctx, func(ctx context.Context) error {
+		var err error
+		notifications, actors, next, err = usecase.Repository.GetNotifications(ctx, request.UserId, page)
+		if err != nil {
+			return err
+		}
+		unreadCount, err = usecase.Repository.CountUnread(ctx, request.UserId)
+		return err
+	})
+	if err != nil {
+		return response.Notifications{}, "", err
+	}
+
+	notificationsResponse := response.Notifications{
+		UnreadCount:   unreadCount,
+		Notifications: []response.Notification{},
+	}
+	for i, notification := range notifications {
+		actor := actors[i]
+		notificationsResponse.Notifications = append(notificationsResponse.Notifications, response.Notification{
+			Id:        notification.Id,
+			Type:      notification.Type,
+			Message:   notificationMessage(notification, actor.Username),
+			PhotoId:   notification.PhotoId,
+			CommentId: notification.CommentId,
+			Actor: response.NotificationActor{
+				Id:             actor.Id,
+				Username:       actor.Username,
+				ProfilePicture: actor.ProfilePicture,
+			},
+			ActorCount: notification.ActorCount,
+			Read:       notification.ReadAt != nil,
+			CreatedAt:  notification.CreatedAt,
+			UpdatedAt:  notification.UpdatedAt,
+		})
+	}
+
+	return notificationsResponse, helper.EncodeCursor(next), nil
+}
+
+func (usecase *NotificationUsecaseImpl) MarkRead(ctx context.Context, request request.NotificationRead) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(usecase.Timeout)*time.Second)
+	defer cancel()
+
+	err := usecase.Validate.Struct(request)
+	if err != nil {
+		return err
+	}
+
+	return usecase.Repository.MarkRead(ctx, request.UserId, request.Ids)
+}
+
+func (usecase *NotificationUsecaseImpl) GetPreferences(ctx context.Context, userId uuid.UUID) (response.NotificationPreferences, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(usecase.Timeout)*time.Second)
+	defer cancel()
+
+	preferences, err := usecase.Repository.GetPreferences(ctx, userId)
+	if err != nil {
+		return response.NotificationPreferences{}, err
+	}
+
+	return getPreferencesResponse(preferences), nil
+}
+
+func (usecase *NotificationUsecaseImpl) UpdatePreferences(ctx context.Context, request request.NotificationPreferences) (response.NotificationPreferences, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(usecase.Timeout)*time.Second)
+	defer cancel()
+
+	err := usecase.Validate.Struct(request)
+	if err != nil {
+		return response.NotificationPreferences{}, err
+	}
+
+	var preferences domain.NotificationPreferences
+	err = usecase.TxManager.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		preferences, err = usecase.Repository.GetPreferences(ctx, request.UserId)
+		if err != nil {
+			return err
+		}
+		for setting, value := range map[*bool]*bool{
+			&preferences.Likes:    request.Likes,
+			&preferences.Comments: request.Comments,
+			&preferences.Follows:  request.Follows,
+			&preferences.Mentions: request.Mentions,
+		} {
+			if value != nil {
+				*setting = *value
+			}
+		}
+
+		preferences, err = usecase.Repository.UpdatePreferences(ctx, preferences)
+		return err
+	})
+	if err != nil {
+		return response.NotificationPreferences{}, err
+	}
+
+	return getPreferencesResponse(preferences), nil
+}
+
+// addressee fills in the recipient of notification when it's left to the photo owner. It
+// reports false for notifications nobody gets, users aren't notified of their own actions.
+func (usecase *NotificationUsecaseImpl) addressee(ctx context.Context, notification domain.Notification) (domain.Notification, bool) {
+	if notification.UserId == uuid.Nil {
+		var err error
+		notification.UserId, err = usecase.PhotoRepository.GetPhotoOwner(ctx, notification.PhotoId)
+		if err != nil {
+			log.Println(err)
+			return notification, false
+		}
+	}
+
+	return notification, notification.UserId != notification.ActorId
+}
+
+// notificationMessage describes notification as in "alice and 12 others liked your photo".
+func notificationMessage(notification domain.Notification, username string) string {
+	actors := username
+	switch {
+	case notification.ActorCount == 2:
+		actors += " and 1 other"
+	case notification.ActorCount > 2:
+		actors += fmt.Sprintf(" and %d others", notification.ActorCount-1)
+	}
+
+	switch notification.Type {
+	case domain.NotificationLike:
+		return actors + " liked your photo"
+	case domain.NotificationComment:
+		return actors + " commented on your photo"
+	case domain.NotificationFollow:
+		return actors + " started following you"
+	case domain.NotificationMention:
+		if notification.CommentId != 0 {
+			return actors + " mentioned you in a comment"
+		}
+		return actors + " mentioned you in a photo"
+	}
+
+	return actors
+}
+
+func getPreferencesResponse(preferences domain.NotificationPreferences) response.NotificationPreferences {
+	return response.NotificationPreferences{
+		Likes:    preferences.Likes,
+		Comments: preferences.Comments,
+		Follows:  preferences.Follows,
+		Mentions: preferences.Mentions,
+	}
+}
@@ -9,8 +9,14 @@ import (
 
 type PhotoUsecase interface {
 	PostPhoto(ctx context.Context, request request.Photo) (response.PostPhoto, error)
-	GetPhoto(ctx context.Context, page string) ([]response.GetPhoto, error)
+	PostPhotoUpload(ctx context.Context, request request.PhotoUpload) (response.PostPhoto, error)
+	GetPhoto(ctx context.Context, request request.Page) ([]response.GetPhoto, string, error)
+	GetFeed(ctx context.Context, request request.Feed) ([]response.GetPhoto, string, error)
+	GetTagPhotos(ctx context.Context, request request.TagPhotos) ([]response.GetPhoto, string, error)
+	SearchPhotos(ctx context.Context, request request.Search) ([]response.GetPhoto, string, error)
+	SearchTags(ctx context.Context, request request.Search) ([]response.Tag, string, error)
 	UpdatePhoto(ctx context.Context, request request.Photo) (response.UpdatePhoto, error)
 	DeletePhoto(ctx context.Context, id int) error
-	GetPhotoById(ctx context.Context, id int) (response.GetPhoto, error)
+	GetPhotoById(ctx context.Context, request request.PhotoDetail) (response.PhotoDetail, string, error)
+	MigrateLegacyPhotos(ctx context.Context) (int, error)
 }
@@ -1,32 +1,75 @@
 package usecase
 
 import (
+	"bytes"
 	"context"
-	"strconv"
+	"fmt"
+	"log"
 	"time"
 
+	"github.com/dihanto/gosnap/internal/app/auth"
+	"github.com/dihanto/gosnap/internal/app/helper"
+	"github.com/dihanto/gosnap/internal/app/imaging"
 	"github.com/dihanto/gosnap/internal/app/repository"
+	"github.com/dihanto/gosnap/internal/app/storage"
 	"github.com/dihanto/gosnap/model/domain"
 	"github.com/dihanto/gosnap/model/web/request"
 	"github.com/dihanto/gosnap/model/web/response"
 	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
 )
 
+// legacyPhotoBatchSize is how many base64 photos are moved to blob storage per round trip.
+const legacyPhotoBatchSize = 50
+
+// PhotoVariantQueue schedules the generation of a photo's resized variants.
+type PhotoVariantQueue interface {
+	Enqueue(photo domain.Photo)
+}
+
 type PhotoUsecaseImpl struct {
-	Repository repository.PhotoRepository
-	Validate   *validator.Validate
-	Timeout    int
+	Repository   repository.PhotoRepository
+	Store        storage.BlobStore
+	Variants     PhotoVariantQueue
+	Notifier     Notifier
+	TxManager    *helper.TxManager
+	Validate     *validator.Validate
+	Timeout      int
+	AllowedTypes []string
+	FeedPageSize int
 }
 
-func NewPhotoUsecase(repository repository.PhotoRepository, validate *validator.Validate, timeout int) PhotoUsecase {
+func NewPhotoUsecase(repository repository.PhotoRepository, store storage.BlobStore, variants PhotoVariantQueue, notifier Notifier, txManager *helper.TxManager, validate *validator.Validate, timeout int, allowedTypes []string, feedPageSize int) PhotoUsecase {
 	return &PhotoUsecaseImpl{
-		Repository: repository,
-		Validate:   validate,
-		Timeout:    timeout,
+		Repository:   repository,
+		Store:        store,
+		Variants:     variants,
+		Notifier:     notifier,
+		TxManager:    txManager,
+		Validate:     validate,
+		Timeout:      timeout,
+		AllowedTypes: allowedTypes,
+		FeedPageSize: feedPageSize,
 	}
 }
 
+// PostPhoto decodes a photo sent as base64 and leaves validating the rest of the request to PostPhotoUpload.
 func (usecase *PhotoUsecaseImpl) PostPhoto(ctx context.Context, request request.Photo) (response.PostPhoto, error) {
+	err := usecase.Validate.Var(request.PhotoBase64, "required")
+	if err != nil {
+		return response.PostPhoto{}, err
+	}
+
+	image, err := helper.DecodeBase64Image(request.PhotoBase64)
+	if err != nil {
+		return response.PostPhoto{}, err
+	}
+
+	upload := newPhotoUpload(request, image)
+	return usecase.PostPhotoUpload(ctx, upload)
+}
+
+func (usecase *PhotoUsecaseImpl) PostPhotoUpload(ctx context.Context, request request.PhotoUpload) (response.PostPhoto, error) {
 	ctx, cancel := context.WithTimeout(ctx, time.Duration(usecase.Timeout)*time.Second)
 	defer cancel()
 
@@ -35,96 +78,174 @@ func (usecase *PhotoUsecaseImpl) PostPhoto(ctx context.Context, request request.
 		return response.PostPhoto{}, err
 	}
 
+	contentType, err := helper.SniffImage(request.Photo, usecase.AllowedTypes)
+	if err != nil {
+		return response.PostPhoto{}, err
+	}
+	err = helper.VerifyImage(request.Photo)
+	if err != nil {
+		return response.PostPhoto{}, err
+	}
+
 	photo := domain.Photo{
-		Title:       request.Title,
-		Caption:     request.Caption,
-		PhotoBase64: request.PhotoBase64,
-		UserId:      request.UserId,
+		Title:    request.Title,
+		Caption:  request.Caption,
+		Entities: helper.ParseEntities(request.Caption),
+		PhotoKey: newPhotoKey(contentType),
+		UserId:   request.UserId,
 	}
 
-	photo, err = usecase.Repository.PostPhoto(ctx, photo)
+	err = usecase.Store.Put(ctx, photo.PhotoKey, request.Photo, request.Size, contentType)
 	if err != nil {
 		return response.PostPhoto{}, err
 	}
 
-	tCreate := time.Unix(int64(photo.CreatedAt), 0)
+	photoKey := photo.PhotoKey
+	photo, err = usecase.Repository.PostPhoto(ctx, photo)
+	if err != nil {
+		usecase.deleteBlob(photoKey)
+		return response.PostPhoto{}, err
+	}
+	usecase.Variants.Enqueue(photo)
+	notifyMentions(ctx, usecase.Notifier, photo.Entities, photo.Id, 0, photo.UserId)
 
 	photoResponse := response.PostPhoto{
-		Id:          photo.Id,
-		Title:       photo.Title,
-		Caption:     photo.Caption,
-		PhotoBase64: photo.PhotoBase64,
-		UserId:      photo.UserId,
-		CreatedAt:   tCreate,
+		Id:           photo.Id,
+		Title:        photo.Title,
+		Caption:      photo.Caption,
+		Entities:     getEntityResponses(photo.Entities),
+		PhotoUrl:     photoURL(usecase.Store, photo, imaging.Original),
+		ThumbnailUrl: photoURL(usecase.Store, photo, imaging.Thumbnail),
+		MediumUrl:    photoURL(usecase.Store, photo, imaging.Medium),
+		UserId:       photo.UserId,
+		CreatedAt:    photo.CreatedAt,
 	}
 
 	return photoResponse, nil
 }
 
-func (usecase *PhotoUsecaseImpl) GetPhoto(ctx context.Context, page string) ([]response.GetPhoto, error) {
+func (usecase *PhotoUsecaseImpl) GetPhoto(ctx context.Context, request request.Page) ([]response.GetPhoto, string, error) {
 	ctx, cancel := context.WithTimeout(ctx, time.Duration(usecase.Timeout)*time.Second)
 	defer cancel()
 
-	limit := 3
-	pageInt, err := strconv.Atoi(page)
+	err := usecase.Validate.Struct(request)
 	if err != nil {
-		return []response.GetPhoto{}, err
+		return []response.GetPhoto{}, "", err
 	}
 
-	var offset int
-	if pageInt == 1 {
-		offset = 0
-	} else {
-		offset = (pageInt - 1) * limit
+	page, err := helper.NewPage(request, helper.DefaultPageLimit)
+	if err != nil {
+		return []response.GetPhoto{}, "", err
 	}
 
-	photos, users, likes, err := usecase.Repository.GetPhoto(ctx, limit, offset)
+	photos, users, likes, next, err := usecase.Repository.GetPhoto(ctx, page)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
-	var photoResponse []response.GetPhoto
-	for _, photo := range photos {
-		tCreate := time.Unix(int64(photo.CreatedAt), 0)
-		tUpdate := time.Unix(int64(photo.UpdatedAt), 0)
+	return usecase.getPhotoResponses(photos, users, likes), helper.EncodeCursor(next), nil
+}
 
-		var user response.User
-		for _, u := range users {
-			if u.Id == photo.UserId {
-				user = response.User{
-					Username:       u.Username,
-					Email:          u.Email,
-					ProfilePicture: u.ProfilePicture,
-				}
-				break
-			}
-		}
+func (usecase *PhotoUsecaseImpl) GetFeed(ctx context.Context, request request.Feed) ([]response.GetPhoto, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(usecase.Timeout)*time.Second)
+	defer cancel()
 
-		var like response.Likes
-		for _, l := range likes {
-			if l.PhotoId == photo.Id {
-				like = response.Likes{
-					LikeCount: l.LikeCount,
-				}
-			}
-		}
+	err := usecase.Validate.Struct(request)
+	if err != nil {
+		return []response.GetPhoto{}, "", err
+	}
 
-		photoResp := response.GetPhoto{
-			Id:          photo.Id,
-			Title:       photo.Title,
-			Caption:     photo.Caption,
-			PhotoBase64: photo.PhotoBase64,
-			UserId:      photo.UserId,
-			CreatedAt:   tCreate,
-			UpdatedAt:   tUpdate,
-			User:        user,
-			Likes:       like,
-		}
+	page, err := helper.NewPage(request.Page, usecase.FeedPageSize)
+	if err != nil {
+		return []response.GetPhoto{}, "", err
+	}
 
-		photoResponse = append(photoResponse, photoResp)
+	photos, users, likes, next, err := usecase.Repository.GetFeed(ctx, request.UserId, page)
+	if err != nil {
+		return nil, "", err
 	}
 
-	return photoResponse, nil
+	return usecase.getPhotoResponses(photos, users, likes), helper.EncodeCursor(next), nil
+}
+
+// GetTagPhotos lists the photos whose caption has a hashtag, newest first. The tag may be
+// given with or without its # and in any case.
+func (usecase *PhotoUsecaseImpl) GetTagPhotos(ctx context.Context, request request.TagPhotos) ([]response.GetPhoto, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(usecase.Timeout)*time.Second)
+	defer cancel()
+
+	request.Tag = helper.NormalizeTag(request.Tag)
+	err := usecase.Validate.Struct(request)
+	if err != nil {
+		return []response.GetPhoto{}, "", err
+	}
+
+	page, err := helper.NewPage(request.Page, helper.DefaultPageLimit)
+	if err != nil {
+		return []response.GetPhoto{}, "", err
+	}
+
+	photos, users, likes, next, err := usecase.Repository.GetTagPhotos(ctx, request.Tag, page)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return usecase.getPhotoResponses(photos, users, likes), helper.EncodeCursor(next), nil
+}
+
+func (usecase *PhotoUsecaseImpl) SearchPhotos(ctx context.Context, request request.Search) ([]response.GetPhoto, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(usecase.Timeout)*time.Second)
+	defer cancel()
+
+	err := usecase.Validate.Struct(request)
+	if err != nil {
+		return []response.GetPhoto{}, "", err
+	}
+
+	page, err := helper.NewPage(request.Page, helper.DefaultPageLimit)
+	if err != nil {
+		return []response.GetPhoto{}, "", err
+	}
+
+	photos, users, likes, next, err := usecase.Repository.SearchPhotos(ctx, request.Query, page)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return usecase.getPhotoResponses(photos, users, likes), helper.EncodeCursor(next), nil
+}
+
+// SearchTags finds the hashtags that start with or look like the query, which may be
+// given with or without its #.
+func (usecase *PhotoUsecaseImpl) SearchTags(ctx context.Context, request request.Search) ([]response.Tag, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(usecase.Timeout)*time.Second)
+	defer cancel()
+
+	request.Query = helper.NormalizeTag(request.Query)
+	err := usecase.Validate.Struct(request)
+	if err != nil {
+		return []response.Tag{}, "", err
+	}
+
+	page, err := helper.NewPage(request.Page, helper.DefaultPageLimit)
+	if err != nil {
+		return []response.Tag{}, "", err
+	}
+
+	hashtags, next, err := usecase.Repository.SearchTags(ctx, request.Query, page)
+	if err != nil {
+		return []response.Tag{}, "", err
+	}
+
+	tags := []response.Tag{}
+	for _, hashtag := range hashtags {
+		tags = append(tags, response.Tag{
+			Name:       hashtag.Name,
+			PhotoCount: hashtag.PhotoCount,
+		})
+	}
+
+	return tags, helper.EncodeCursor(next), nil
 }
 
 func (usecase *PhotoUsecaseImpl) UpdatePhoto(ctx context.Context, request request.Photo) (response.UpdatePhoto, error) {
@@ -137,23 +258,39 @@ func (usecase *PhotoUsecaseImpl) UpdatePhoto(ctx context.Context, request reques
 	}
 
 	photo := domain.Photo{
-		Id:      request.Id,
-		Caption: request.Caption,
-		UserId:  request.UserId,
+		Id:       request.Id,
+		Caption:  request.Caption,
+		Entities: helper.ParseEntities(request.Caption),
+		UserId:   request.UserId,
 	}
 
-	photo, err = usecase.Repository.UpdatePhoto(ctx, photo)
+	err = usecase.TxManager.WithinTx(ctx, func(ctx context.Context) error {
+		ownerId, err := usecase.Repository.GetPhotoOwner(ctx, request.Id)
+		if err != nil {
+			return err
+		}
+		err = auth.AuthorizeOwner(ctx, ownerId)
+		if err != nil {
+			return err
+		}
+
+		photo, err = usecase.Repository.UpdatePhoto(ctx, photo)
+		return err
+	})
 	if err != nil {
 		return response.UpdatePhoto{}, err
 	}
 
 	photoResponse := response.UpdatePhoto{
-		Id:          photo.Id,
-		Caption:     photo.Caption,
-		PhotoBase64: photo.PhotoBase64,
-		UserId:      photo.UserId,
-		UpdatedAt:   time.Unix(int64(photo.UpdatedAt), 0),
-		CreatedAt:   time.Unix(int64(photo.CreatedAt), 0),
+		Id:           photo.Id,
+		Caption:      photo.Caption,
+		Entities:     getEntityResponses(photo.Entities),
+		PhotoUrl:     photoURL(usecase.Store, photo, imaging.Original),
+		ThumbnailUrl: photoURL(usecase.Store, photo, imaging.Thumbnail),
+		MediumUrl:    photoURL(usecase.Store, photo, imaging.Medium),
+		UserId:       photo.UserId,
+		UpdatedAt:    photo.UpdatedAt,
+		CreatedAt:    photo.CreatedAt,
 	}
 
 	return photoResponse, nil
@@ -163,40 +300,241 @@ func (usecase *PhotoUsecaseImpl) DeletePhoto(ctx context.Context, id int) error
 	ctx, cancel := context.WithTimeout(ctx, time.Duration(usecase.Timeout)*time.Second)
 	defer cancel()
 
-	err := usecase.Repository.DeletePhoto(ctx, id)
+	return usecase.TxManager.WithinTx(ctx, func(ctx context.Context) error {
+		ownerId, err := usecase.Repository.GetPhotoOwner(ctx, id)
+		if err != nil {
+			return err
+		}
+		err = auth.AuthorizeOwnerOrModerator(ctx, ownerId)
+		if err != nil {
+			return err
+		}
+
+		return usecase.Repository.DeletePhoto(ctx, id)
+	})
+}
+
+func (usecase *PhotoUsecaseImpl) GetPhotoById(ctx context.Context, request request.PhotoDetail) (response.PhotoDetail, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(usecase.Timeout)*time.Second)
+	defer cancel()
+
+	err := usecase.Validate.Struct(request)
 	if err != nil {
-		return err
+		return response.PhotoDetail{}, "", err
+	}
+
+	page, err := helper.NewPage(request.Page, helper.DefaultPageLimit)
+	if err != nil {
+		return response.PhotoDetail{}, "", err
+	}
+
+	detail, next, err := usecase.Repository.GetPhotoById(ctx, request.Id, request.UserId, page)
+	if err != nil {
+		return response.PhotoDetail{}, "", err
+	}
+
+	comments := []response.PhotoDetailComment{}
+	for i, comment := range detail.Comments {
+		commenter := detail.Commenters[i]
+		comments = append(comments, response.PhotoDetailComment{
+			Id:         comment.Id,
+			Message:    comment.Message,
+			Entities:   getEntityResponses(comment.Entities),
+			UserId:     comment.UserId,
+			ReplyCount: comment.ReplyCount,
+			LikeCount:  comment.LikeCount,
+			CreatedAt:  comment.CreatedAt,
+			UpdatedAt:  comment.UpdatedAt,
+			User: response.UserComment{
+				Id:       commenter.Id,
+				Email:    commenter.Email,
+				Username: commenter.Username,
+			},
+		})
+	}
+
+	photo := detail.Photo
+	photoResponse := response.PhotoDetail{
+		Id:           photo.Id,
+		Title:        photo.Title,
+		Caption:      photo.Caption,
+		Entities:     getEntityResponses(photo.Entities),
+		PhotoUrl:     photoURL(usecase.Store, photo, imaging.Original),
+		ThumbnailUrl: photoURL(usecase.Store, photo, imaging.Thumbnail),
+		MediumUrl:    photoURL(usecase.Store, photo, imaging.Medium),
+		UserId:       photo.UserId,
+		CreatedAt:    photo.CreatedAt,
+		UpdatedAt:    photo.UpdatedAt,
+		User: response.PhotoAuthor{
+			Id:             detail.User.Id,
+			Username:       detail.User.Username,
+			Name:           detail.User.Name,
+			ProfilePicture: detail.User.ProfilePicture,
+		},
+		Likes: response.PhotoLikes{
+			LikeCount: detail.Like.LikeCount,
+			Liked:     detail.Liked,
+		},
+		Comments: comments,
 	}
 
-	return nil
+	return photoResponse, helper.EncodeCursor(next), nil
+}
+
+// MigrateLegacyPhotos moves images still stored inline in photo_base64 into the
+// blob store, one batch at a time, and returns how many photos were moved.
+func (usecase *PhotoUsecaseImpl) MigrateLegacyPhotos(ctx context.Context) (int, error) {
+	migrated := 0
+	afterId := 0
+	for {
+		count, lastId, err := usecase.migrateLegacyPhotoBatch(ctx, afterId)
+		migrated += count
+		if err != nil {
+			return migrated, err
+		}
+		if lastId == afterId {
+			return migrated, nil
+		}
+		afterId = lastId
+	}
 }
 
-func (usecase *PhotoUsecaseImpl) GetPhotoById(ctx context.Context, id int) (response.GetPhoto, error) {
+// migrateLegacyPhotoBatch moves the photos after afterId into blob storage and returns how many
+// it moved and the id of the last photo it looked at. Photos whose image is corrupt or of a type
+// that isn't allowed are logged and left in place, so they don't stop the migration.
+func (usecase *PhotoUsecaseImpl) migrateLegacyPhotoBatch(ctx context.Context, afterId int) (int, int, error) {
 	ctx, cancel := context.WithTimeout(ctx, time.Duration(usecase.Timeout)*time.Second)
 	defer cancel()
 
-	photo, user, like, err := usecase.Repository.GetPhotoById(ctx, id)
+	photos, err := usecase.Repository.GetLegacyPhotos(ctx, afterId, legacyPhotoBatchSize)
 	if err != nil {
-		return response.GetPhoto{}, err
+		return 0, afterId, err
 	}
 
-	userResponse := response.User{
-		Username:       user.Username,
-		ProfilePicture: user.ProfilePicture,
+	migrated := 0
+	for _, photo := range photos {
+		afterId = photo.Id
+		image, err := helper.DecodeBase64Image(photo.PhotoBase64)
+		if err != nil {
+			log.Printf("skip photo %d: %v", photo.Id, err)
+			continue
+		}
+		contentType, err := helper.SniffImage(bytes.NewReader(image), usecase.AllowedTypes)
+		if err == nil {
+			err = helper.VerifyImage(bytes.NewReader(image))
+		}
+		if err != nil {
+			log.Printf("skip photo %d: %v", photo.Id, err)
+			continue
+		}
+
+		photo.PhotoKey = newPhotoKey(contentType)
+		err = usecase.Store.Put(ctx, photo.PhotoKey, bytes.NewReader(image), int64(len(image)), contentType)
+		if err != nil {
+			return migrated, afterId, fmt.Errorf("photo %d: %w", photo.Id, err)
+		}
+
+		err = usecase.Repository.UpdatePhotoKey(ctx, photo)
+		if err != nil {
+			usecase.deleteBlob(photo.PhotoKey)
+			return migrated, afterId, fmt.Errorf("photo %d: %w", photo.Id, err)
+		}
+		migrated++
 	}
 
-	likeResponse := response.Likes{
-		LikeCount: like.LikeCount,
+	return migrated, afterId, nil
+}
+
+// getPhotoResponses joins photos with their authors and like counts for list endpoints.
+func (usecase *PhotoUsecaseImpl) getPhotoResponses(photos []domain.Photo, users []domain.User, likes []domain.Like) []response.GetPhoto {
+	var photoResponse []response.GetPhoto
+	for _, photo := range photos {
+		var user response.User
+		for _, u := range users {
+			if u.Id == photo.UserId {
+				user = response.User{
+					Username:       u.Username,
+					Email:          u.Email,
+					ProfilePicture: u.ProfilePicture,
+				}
+				break
+			}
+		}
+
+		var like response.Likes
+		for _, l := range likes {
+			if l.PhotoId == photo.Id {
+				like = response.Likes{
+					LikeCount: l.LikeCount,
+				}
+			}
+		}
+
+		photoResp := response.GetPhoto{
+			Id:           photo.Id,
+			Title:        photo.Title,
+			Caption:      photo.Caption,
+			Entities:     getEntityResponses(photo.Entities),
+			PhotoUrl:     photoURL(usecase.Store, photo, imaging.Original),
+			ThumbnailUrl: photoURL(usecase.Store, photo, imaging.Thumbnail),
+			MediumUrl:    photoURL(usecase.Store, photo, imaging.Medium),
+			UserId:       photo.UserId,
+			CreatedAt:    photo.CreatedAt,
+			UpdatedAt:    photo.UpdatedAt,
+			User:         user,
+			Likes:        like,
+		}
+
+		photoResponse = append(photoResponse, photoResp)
 	}
 
-	photoResponse := response.GetPhoto{
-		Id:          id,
-		Caption:     photo.Caption,
-		PhotoBase64: photo.PhotoBase64,
-		UserId:      photo.UserId,
-		User:        userResponse,
-		Likes:       likeResponse,
+	return photoResponse
+}
+
+// photoURL is the URL of a variant of photo. While the variants are still being generated it
+// is where they will be stored: the upload itself is never served.
+func photoURL(store storage.BlobStore, photo domain.Photo, variant imaging.Variant) string {
+	if photo.PhotoKey == "" {
+		return ""
+	}
+	if photo.ThumbnailKey == "" {
+		return store.URL(imaging.VariantKey(photo.PhotoKey, variant))
 	}
 
-	return photoResponse, nil
+	switch variant {
+	case imaging.Thumbnail:
+		return store.URL(photo.ThumbnailKey)
+	case imaging.Medium:
+		return store.URL(photo.MediumKey)
+	default:
+		return store.URL(photo.PhotoKey)
+	}
+}
+
+// deleteBlob removes an object that was uploaded for a photo whose row never got written.
+func (usecase *PhotoUsecaseImpl) deleteBlob(key string) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(usecase.Timeout)*time.Second)
+	defer cancel()
+
+	err := usecase.Store.Delete(ctx, key)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// newPhotoUpload adapts a JSON photo request carrying base64 data to the upload flow.
+func newPhotoUpload(photo request.Photo, image []byte) request.PhotoUpload {
+	return request.PhotoUpload{
+		Title:   photo.Title,
+		Caption: photo.Caption,
+		UserId:  photo.UserId,
+		Photo:   bytes.NewReader(image),
+		Size:    int64(len(image)),
+	}
+}
+
+// newPhotoKey is where a photo is kept as uploaded, out of reach of clients, until the
+// worker has replaced it by its variants.
+func newPhotoKey(contentType string) string {
+	return storage.PrivatePrefix + uuid.New().String() + helper.ImageExtension(contentType)
 }
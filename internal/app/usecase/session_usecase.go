@@ -0,0 +1,15 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/dihanto/gosnap/model/domain"
+	"github.com/dihanto/gosnap/model/web/request"
+	"github.com/dihanto/gosnap/model/web/response"
+)
+
+type SessionUsecase interface {
+	CreateSession(ctx context.Context, user domain.User) (response.Session, error)
+	RefreshSession(ctx context.Context, request request.RefreshToken) (response.Session, error)
+	RevokeSession(ctx context.Context, request request.RefreshToken) error
+}
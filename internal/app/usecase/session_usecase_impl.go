@@ -0,0 +1,158 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/dihanto/gosnap/internal/app/auth"
+	"github.com/dihanto/gosnap/internal/app/repository"
+	"github.com/dihanto/gosnap/model/domain"
+	"github.com/dihanto/gosnap/model/web/request"
+	"github.com/dihanto/gosnap/model/web/response"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+type SessionUsecaseImpl struct {
+	Repository repository.SessionRepository
+	Tokens     *auth.TokenManager
+	Validate   *validator.Validate
+	Timeout    int
+	RefreshTTL time.Duration
+}
+
+func NewSessionUsecase(repository repository.SessionRepository, tokens *auth.TokenManager, validate *validator.Validate, timeout int, refreshTTL time.Duration) SessionUsecase {
+	return &SessionUsecaseImpl{
+		Repository: repository,
+		Tokens:     tokens,
+		Validate:   validate,
+		Timeout:    timeout,
+		RefreshTTL: refreshTTL,
+	}
+}
+
+// CreateSession starts a new refresh token family for a user who just logged in.
+func (usecase *SessionUsecaseImpl) CreateSession(ctx context.Context, user domain.User) (response.Session, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(usecase.Timeout)*time.Second)
+	defer cancel()
+
+	refreshToken, token, err := usecase.newRefreshToken(user.Id, uuid.New())
+	if err != nil {
+		return response.Session{}, err
+	}
+
+	err = usecase.Repository.CreateRefreshToken(ctx, token)
+	if err != nil {
+		return response.Session{}, err
+	}
+
+	return usecase.newSession(user, refreshToken)
+}
+
+// RefreshSession exchanges a refresh token for a new access token and a new refresh token.
+// Presenting a token that was already exchanged revokes its whole family, since either the
+// client or an attacker is holding a stolen copy.
+func (usecase *SessionUsecaseImpl) RefreshSession(ctx context.Context, request request.RefreshToken) (response.Session, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(usecase.Timeout)*time.Second)
+	defer cancel()
+
+	err := usecase.Validate.Struct(request)
+	if err != nil {
+		return response.Session{}, err
+	}
+
+	current, user, err := usecase.Repository.GetRefreshToken(ctx, auth.HashRefreshToken(request.RefreshToken))
+	if err == sql.ErrNoRows {
+		return response.Session{}, auth.ErrUnauthorized
+	}
+	if err != nil {
+		return response.Session{}, err
+	}
+
+	if current.RevokedAt != nil {
+		return response.Session{}, usecase.revokeReusedFamily(ctx, current.FamilyId)
+	}
+	if !time.Now().Before(current.ExpiresAt) {
+		return response.Session{}, auth.ErrUnauthorized
+	}
+
+	refreshToken, next, err := usecase.newRefreshToken(user.Id, current.FamilyId)
+	if err != nil {
+		return response.Session{}, err
+	}
+
+	err = usecase.Repository.RotateRefreshToken(ctx, current, next)
+	if errors.Is(err, repository.ErrRefreshTokenReused) {
+		return response.Session{}, usecase.revokeReusedFamily(ctx, current.FamilyId)
+	}
+	if err != nil {
+		return response.Session{}, err
+	}
+
+	return usecase.newSession(user, refreshToken)
+}
+
+// RevokeSession logs out by revoking the refresh token family the given token belongs to.
+func (usecase *SessionUsecaseImpl) RevokeSession(ctx context.Context, request request.RefreshToken) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(usecase.Timeout)*time.Second)
+	defer cancel()
+
+	err := usecase.Validate.Struct(request)
+	if err != nil {
+		return err
+	}
+
+	token, _, err := usecase.Repository.GetRefreshToken(ctx, auth.HashRefreshToken(request.RefreshToken))
+	if err == sql.ErrNoRows {
+		return auth.ErrUnauthorized
+	}
+	if err != nil {
+		return err
+	}
+
+	return usecase.Repository.RevokeRefreshTokenFamily(ctx, token.FamilyId)
+}
+
+func (usecase *SessionUsecaseImpl) revokeReusedFamily(ctx context.Context, familyId uuid.UUID) error {
+	err := usecase.Repository.RevokeRefreshTokenFamily(ctx, familyId)
+	if err != nil {
+		return err
+	}
+
+	return auth.ErrUnauthorized
+}
+
+func (usecase *SessionUsecaseImpl) newRefreshToken(userId uuid.UUID, familyId uuid.UUID) (string, domain.RefreshToken, error) {
+	refreshToken, hash, err := auth.NewRefreshToken()
+	if err != nil {
+		return "", domain.RefreshToken{}, err
+	}
+
+	now := time.Now()
+	token := domain.RefreshToken{
+		Id:        uuid.New(),
+		UserId:    userId,
+		FamilyId:  familyId,
+		TokenHash: hash,
+		CreatedAt: now,
+		ExpiresAt: now.Add(usecase.RefreshTTL),
+	}
+
+	return refreshToken, token, nil
+}
+
+func (usecase *SessionUsecaseImpl) newSession(user domain.User, refreshToken string) (response.Session, error) {
+	accessToken, err := usecase.Tokens.Issue(user.Id, user.Username, []string{user.Role})
+	if err != nil {
+		return response.Session{}, err
+	}
+
+	return response.Session{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(usecase.Tokens.TTL.Seconds()),
+	}, nil
+}
@@ -9,7 +9,7 @@ import (
 
 type SocialMediaUsecase interface {
 	PostSocialMedia(ctx context.Context, request request.SocialMedia) (response.PostSocialMedia, error)
-	GetSocialMedia(ctx context.Context) ([]response.GetSocialMedia, error)
+	GetSocialMedia(ctx context.Context, request request.Page) ([]response.GetSocialMedia, string, error)
 	UpdateSocialMedia(ctx context.Context, request request.SocialMedia) (response.UpdateSocialMedia, error)
 	DeleteSocialMedia(ctx context.Context, id int) error
 }
@@ -4,6 +4,8 @@ import (
 	"context"
 	"time"
 
+	"github.com/dihanto/gosnap/internal/app/auth"
+	"github.com/dihanto/gosnap/internal/app/helper"
 	"github.com/dihanto/gosnap/internal/app/repository"
 	"github.com/dihanto/gosnap/model/domain"
 	"github.com/dihanto/gosnap/model/web/request"
@@ -13,13 +15,15 @@ import (
 
 type SocialMediaUsecaseImpl struct {
 	Repository repository.SocialMediaRepository
+	TxManager  *helper.TxManager
 	Validate   *validator.Validate
 	Timeout    int
 }
 
-func NewSocialMediaUsecase(repository repository.SocialMediaRepository, validate *validator.Validate, timeout int) SocialMediaUsecase {
+func NewSocialMediaUsecase(repository repository.SocialMediaRepository, txManager *helper.TxManager, validate *validator.Validate, timeout int) SocialMediaUsecase {
 	return &SocialMediaUsecaseImpl{
 		Repository: repository,
+		TxManager:  txManager,
 		Validate:   validate,
 		Timeout:    timeout,
 	}
@@ -50,19 +54,29 @@ func (usecase *SocialMediaUsecaseImpl) PostSocialMedia(ctx context.Context, requ
 		Name:           socialMedia.Name,
 		SocialMediaUrl: socialMedia.SocialMediaUrl,
 		UserId:         socialMedia.UserId,
-		CreatedAt:      time.Unix(int64(socialMedia.CreatedAt), 0),
+		CreatedAt:      socialMedia.CreatedAt,
 	}
 
 	return socialMediaResponse, nil
 }
 
-func (usecase *SocialMediaUsecaseImpl) GetSocialMedia(ctx context.Context) ([]response.GetSocialMedia, error) {
+func (usecase *SocialMediaUsecaseImpl) GetSocialMedia(ctx context.Context, request request.Page) ([]response.GetSocialMedia, string, error) {
 	ctx, cancel := context.WithTimeout(ctx, time.Duration(usecase.Timeout)*time.Second)
 	defer cancel()
 
-	socialMedias, users, err := usecase.Repository.GetSocialMedia(ctx)
+	err := usecase.Validate.Struct(request)
+	if err != nil {
+		return nil, "", err
+	}
+
+	page, err := helper.NewPage(request, helper.DefaultPageLimit)
 	if err != nil {
-		return nil, err
+		return nil, "", err
+	}
+
+	socialMedias, users, next, err := usecase.Repository.GetSocialMedia(ctx, page)
+	if err != nil {
+		return nil, "", err
 	}
 
 	var socialMediasResponse []response.GetSocialMedia
@@ -84,14 +98,14 @@ func (usecase *SocialMediaUsecaseImpl) GetSocialMedia(ctx context.Context) ([]re
 			Name:           socialMedia.Name,
 			SocialMediaUrl: socialMedia.SocialMediaUrl,
 			UserId:         socialMedia.UserId,
-			CreatedAt:      time.Unix(int64(socialMedia.CreatedAt), 0),
-			UpdatedAt:      time.Unix(int64(socialMedia.UpdatedAt), 0),
+			CreatedAt:      socialMedia.CreatedAt,
+			UpdatedAt:      socialMedia.UpdatedAt,
 			User:           userResponse,
 		}
 		socialMediasResponse = append(socialMediasResponse, socialMediaResponse)
 	}
 
-	return socialMediasResponse, nil
+	return socialMediasResponse, helper.EncodeCursor(next), nil
 }
 
 func (usecase *SocialMediaUsecaseImpl) UpdateSocialMedia(ctx context.Context, request request.SocialMedia) (response.UpdateSocialMedia, error) {
@@ -109,7 +123,19 @@ func (usecase *SocialMediaUsecaseImpl) UpdateSocialMedia(ctx context.Context, re
 		SocialMediaUrl: request.SocialMediaUrl,
 	}
 
-	socialMedia, err = usecase.Repository.UpdateSocialMedia(ctx, socialMedia)
+	err = usecase.TxManager.WithinTx(ctx, func(ctx context.Context) error {
+		ownerId, err := usecase.Repository.GetSocialMediaOwner(ctx, request.Id)
+		if err != nil {
+			return err
+		}
+		err = auth.AuthorizeOwner(ctx, ownerId)
+		if err != nil {
+			return err
+		}
+
+		socialMedia, err = usecase.Repository.UpdateSocialMedia(ctx, socialMedia)
+		return err
+	})
 	if err != nil {
 		return response.UpdateSocialMedia{}, err
 	}
@@ -119,7 +145,7 @@ func (usecase *SocialMediaUsecaseImpl) UpdateSocialMedia(ctx context.Context, re
 		Name:           socialMedia.Name,
 		SocialMediaUrl: socialMedia.SocialMediaUrl,
 		UserId:         socialMedia.UserId,
-		UpdatedAt:      time.Unix(int64(socialMedia.UpdatedAt), 0),
+		UpdatedAt:      socialMedia.UpdatedAt,
 	}
 
 	return socialMediaResponse, nil
@@ -129,10 +155,16 @@ func (usecase *SocialMediaUsecaseImpl) DeleteSocialMedia(ctx context.Context, id
 	ctx, cancel := context.WithTimeout(ctx, time.Duration(usecase.Timeout)*time.Second)
 	defer cancel()
 
-	err := usecase.Repository.DeleteSocialMedia(ctx, id)
-	if err != nil {
-		return err
-	}
+	return usecase.TxManager.WithinTx(ctx, func(ctx context.Context) error {
+		ownerId, err := usecase.Repository.GetSocialMediaOwner(ctx, id)
+		if err != nil {
+			return err
+		}
+		err = auth.AuthorizeOwnerOrModerator(ctx, ownerId)
+		if err != nil {
+			return err
+		}
 
-	return nil
+		return usecase.Repository.DeleteSocialMedia(ctx, id)
+	})
 }
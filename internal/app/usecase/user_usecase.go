@@ -3,6 +3,7 @@ package usecase
 import (
 	"context"
 
+	"github.com/dihanto/gosnap/model/domain"
 	"github.com/dihanto/gosnap/model/web/request"
 	"github.com/dihanto/gosnap/model/web/response"
 	"github.com/google/uuid"
@@ -10,9 +11,15 @@ import (
 
 type UserUsecase interface {
 	UserRegister(ctx context.Context, request request.UserRegister) (response.UserRegister, error)
-	UserLogin(ctx context.Context, username, password string) (bool, uuid.UUID, error)
+	UserLogin(ctx context.Context, username, password string) (bool, domain.User, error)
 	UserUpdate(ctx context.Context, request request.UserUpdate) (response.UserUpdate, error)
 	UserDelete(ctx context.Context, id uuid.UUID) error
 	FindUser(ctx context.Context, id uuid.UUID) (response.FindUser, error)
-	FindAllUser(ctx context.Context, username string) (users []response.FindAllUser, err error)
+	SuspendUser(ctx context.Context, id uuid.UUID) error
+	UnsuspendUser(ctx context.Context, id uuid.UUID) error
+	UpdateUserRole(ctx context.Context, request request.UserRole) error
+	FindAllUser(ctx context.Context, userId uuid.UUID, request request.Page) (users []response.FindAllUser, nextCursor string, err error)
+	FindUserByUsername(ctx context.Context, username string) (response.UserProfile, error)
+	GetMentions(ctx context.Context, request request.Mentions) ([]response.Mention, string, error)
+	SearchUsers(ctx context.Context, request request.Search) ([]response.SearchUser, string, error)
 }
@@ -4,6 +4,8 @@ import (
 	"context"
 	"time"
 
+	"github.com/dihanto/gosnap/internal/app/auth"
+	"github.com/dihanto/gosnap/internal/app/helper"
 	"github.com/dihanto/gosnap/internal/app/repository"
 	"github.com/dihanto/gosnap/model/domain"
 	"github.com/dihanto/gosnap/model/web/request"
@@ -13,27 +15,33 @@ import (
 )
 
 type UserUsecaseImpl struct {
-	Repository repository.UserRepository
-	Validate   *validator.Validate
-	Timeout    int
+	Repository       repository.UserRepository
+	PhotoRepository  repository.PhotoRepository
+	TxManager        *helper.TxManager
+	Validate         *validator.Validate
+	Timeout          int
+	UsernameCooldown time.Duration
 }
 
-func NewUserUsecase(repository repository.UserRepository, validate *validator.Validate, timeout int) UserUsecase {
+func NewUserUsecase(repository repository.UserRepository, photoRepository repository.PhotoRepository, txManager *helper.TxManager, validate *validator.Validate, timeout int, usernameCooldown time.Duration) UserUsecase {
 	return &UserUsecaseImpl{
-		Repository: repository,
-		Validate:   validate,
-		Timeout:    timeout,
+		Repository:       repository,
+		PhotoRepository:  photoRepository,
+		TxManager:        txManager,
+		Validate:         validate,
+		Timeout:          timeout,
+		UsernameCooldown: usernameCooldown,
 	}
 }
 func (usecase *UserUsecaseImpl) UserRegister(ctx context.Context, request request.UserRegister) (response.UserRegister, error) {
-	err := usecase.Validate.Struct(request)
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(usecase.Timeout)*time.Second)
+	defer cancel()
+
+	err := usecase.Validate.StructCtx(ctx, request)
 	if err != nil {
 		return response.UserRegister{}, err
 	}
 
-	ctx, cancel := context.WithTimeout(ctx, time.Duration(usecase.Timeout)*time.Second)
-	defer cancel()
-
 	user := domain.User{
 		Email:    request.Email,
 		Username: request.Username,
@@ -42,32 +50,42 @@ func (usecase *UserUsecaseImpl) UserRegister(ctx context.Context, request reques
 	}
 	user.Id = uuid.New()
 
-	user, err = usecase.Repository.UserRegister(ctx, user)
+	err = usecase.TxManager.WithinTx(ctx, func(ctx context.Context) error {
+		err := usecase.checkUsernameReserved(ctx, request.Username, uuid.Nil)
+		if err != nil {
+			return err
+		}
+
+		user, err = usecase.Repository.UserRegister(ctx, user)
+		return err
+	})
 	if err != nil {
 		return response.UserRegister{}, err
 	}
 
-	tCreate := time.Unix(int64(user.CreatedAt), 0)
 	userResponse := response.UserRegister{
 		Email:     user.Email,
 		Username:  user.Username,
 		Password:  user.Password,
-		CreatedAT: tCreate,
+		CreatedAT: user.CreatedAt,
 	}
 
 	return userResponse, nil
 }
 
-func (usecase *UserUsecaseImpl) UserLogin(ctx context.Context, username string, password string) (bool, uuid.UUID, error) {
+func (usecase *UserUsecaseImpl) UserLogin(ctx context.Context, username string, password string) (bool, domain.User, error) {
 	ctx, cancel := context.WithTimeout(ctx, time.Duration(usecase.Timeout)*time.Second)
 	defer cancel()
 
-	response, id, err := usecase.Repository.UserLogin(ctx, username, password)
+	response, user, err := usecase.Repository.UserLogin(ctx, username, password)
 	if err != nil {
-		return false, uuid.Nil, err
+		return false, domain.User{}, err
+	}
+	if user.SuspendedAt != nil {
+		return false, domain.User{}, auth.ErrAccountSuspended
 	}
 
-	return response, id, nil
+	return response, user, nil
 }
 
 func (usecase *UserUsecaseImpl) UserUpdate(ctx context.Context, request request.UserUpdate) (response.UserUpdate, error) {
@@ -86,18 +104,29 @@ func (usecase *UserUsecaseImpl) UserUpdate(ctx context.Context, request request.
 		ProfilePicture: request.ProfilePicture,
 	}
 
-	userResponse, err := usecase.Repository.UserUpdate(ctx, userReq)
+	var userResponse domain.User
+	err = usecase.TxManager.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if request.Username != "" {
+			err = usecase.checkUsernameReserved(ctx, request.Username, request.Id)
+			if err != nil {
+				return err
+			}
+		}
+
+		userResponse, err = usecase.Repository.UserUpdate(ctx, userReq)
+		return err
+	})
 	if err != nil {
 		return response.UserUpdate{}, err
 	}
 
-	tUpdate := time.Unix(int64(userResponse.UpdatedAt), 0)
 	user := response.UserUpdate{
 		Id:             userResponse.Id,
 		Email:          userResponse.Email,
 		Username:       userResponse.Username,
 		ProfilePicture: userResponse.ProfilePicture,
-		UpdatedAt:      tUpdate,
+		UpdatedAt:      userResponse.UpdatedAt,
 	}
 
 	return user, nil
@@ -107,7 +136,14 @@ func (usecase *UserUsecaseImpl) UserDelete(ctx context.Context, id uuid.UUID) er
 	ctx, cancel := context.WithTimeout(ctx, time.Duration(usecase.Timeout)*time.Second)
 	defer cancel()
 
-	err := usecase.Repository.UserDelete(ctx, id)
+	// the account and its photos go together, or neither does
+	err := usecase.TxManager.WithinTx(ctx, func(ctx context.Context) error {
+		err := usecase.Repository.UserDelete(ctx, id)
+		if err != nil {
+			return err
+		}
+		return usecase.PhotoRepository.DeleteUserPhotos(ctx, id)
+	})
 	if err != nil {
 		return err
 	}
@@ -126,36 +162,237 @@ func (usecase *UserUsecaseImpl) FindUser(ctx context.Context, id uuid.UUID) (res
 
 	user, err := usecase.Repository.FindUser(ctx, id)
 	if err != nil {
-		return response.FindUser{}, nil
+		return response.FindUser{}, err
 	}
 
 	userResponse := response.FindUser{
 		Username:       user.Username,
 		Name:           user.Name,
 		ProfilePicture: user.ProfilePicture,
+		Role:           user.Role,
 	}
 
 	return userResponse, nil
 }
 
-func (usecase *UserUsecaseImpl) FindAllUser(ctx context.Context, username string) (users []response.FindAllUser, err error) {
+func (usecase *UserUsecaseImpl) SuspendUser(ctx context.Context, id uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(usecase.Timeout)*time.Second)
+	defer cancel()
+
+	err := usecase.Validate.Var(id, "required")
+	if err != nil {
+		return err
+	}
+
+	err = checkNotSelf(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return usecase.Repository.SuspendUser(ctx, id)
+}
+
+func (usecase *UserUsecaseImpl) UnsuspendUser(ctx context.Context, id uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(usecase.Timeout)*time.Second)
+	defer cancel()
+
+	err := usecase.Validate.Var(id, "required")
+	if err != nil {
+		return err
+	}
+
+	return usecase.Repository.UnsuspendUser(ctx, id)
+}
+
+func (usecase *UserUsecaseImpl) UpdateUserRole(ctx context.Context, request request.UserRole) error {
 	ctx, cancel := context.WithTimeout(ctx, time.Duration(usecase.Timeout)*time.Second)
 	defer cancel()
 
-	usersRepository, err := usecase.Repository.FindAllUser(ctx)
+	err := usecase.Validate.Struct(request)
+	if err != nil {
+		return err
+	}
+
+	err = checkNotSelf(ctx, request.Id)
+	if err != nil {
+		return err
+	}
+
+	return usecase.Repository.UpdateUserRole(ctx, request.Id, request.Role)
+}
+
+// checkNotSelf keeps an admin from suspending or demoting themselves, which could leave no one able to undo it.
+func checkNotSelf(ctx context.Context, id uuid.UUID) error {
+	principal, err := auth.PrincipalFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	if principal.UserId == id {
+		return repository.ErrModerateSelf
+	}
+
+	return nil
+}
+
+func (usecase *UserUsecaseImpl) FindAllUser(ctx context.Context, userId uuid.UUID, request request.Page) (users []response.FindAllUser, nextCursor string, err error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(usecase.Timeout)*time.Second)
+	defer cancel()
+
+	err = usecase.Validate.Struct(request)
+	if err != nil {
+		return
+	}
+
+	page, err := helper.NewPage(request, helper.DefaultPageLimit)
+	if err != nil {
+		return
+	}
+
+	usersRepository, next, err := usecase.Repository.FindAllUser(ctx, userId, page)
 	if err != nil {
 		return
 	}
 
 	for _, userRepository := range usersRepository {
-		if username != userRepository.Username {
-			user := response.FindAllUser{
-				Username:       userRepository.Username,
-				ProfilePicture: userRepository.ProfilePicture,
-			}
-			users = append(users, user)
+		user := response.FindAllUser{
+			Username:       userRepository.Username,
+			ProfilePicture: userRepository.ProfilePicture,
 		}
+		users = append(users, user)
 	}
+	nextCursor = helper.EncodeCursor(next)
 
 	return
 }
+
+// FindUserByUsername returns the public profile of a user. The profile's username differs from
+// the one asked for when the user has since been renamed.
+func (usecase *UserUsecaseImpl) FindUserByUsername(ctx context.Context, username string) (response.UserProfile, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(usecase.Timeout)*time.Second)
+	defer cancel()
+
+	err := usecase.Validate.Var(username, "required")
+	if err != nil {
+		return response.UserProfile{}, err
+	}
+
+	user, err := usecase.Repository.FindUserByUsername(ctx, username)
+	if err != nil {
+		return response.UserProfile{}, err
+	}
+
+	profile := response.UserProfile{
+		Id:             user.Id,
+		Username:       user.Username,
+		Name:           user.Name,
+		ProfilePicture: user.ProfilePicture,
+		FollowerCount:  user.FollowerCount,
+		FollowingCount: user.FollowingCount,
+	}
+
+	return profile, nil
+}
+
+// GetMentions lists, newest first, the photo captions and comments that mention a user. A username
+// the user went by before finds them too.
+func (usecase *UserUsecaseImpl) GetMentions(ctx context.Context, request request.Mentions) ([]response.Mention, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(usecase.Timeout)*time.Second)
+	defer cancel()
+
+	err := usecase.Validate.Struct(request)
+	if err != nil {
+		return nil, "", err
+	}
+
+	page, err := helper.NewPage(request.Page, helper.DefaultPageLimit)
+	if err != nil {
+		return nil, "", err
+	}
+
+	user, err := usecase.Repository.FindUserByUsername(ctx, request.Username)
+	if err != nil {
+		return nil, "", err
+	}
+
+	mentions, authors, next, err := usecase.Repository.GetMentions(ctx, user.Id, page)
+	if err != nil {
+		return nil, "", err
+	}
+
+	mentionResponses := []response.Mention{}
+	for i, mention := range mentions {
+		author := authors[i]
+		mentionResponses = append(mentionResponses, response.Mention{
+			PhotoId:   mention.PhotoId,
+			CommentId: mention.CommentId,
+			Text:      mention.Text,
+			Entities:  getEntityResponses(mention.Entities),
+			UserId:    mention.UserId,
+			CreatedAt: mention.CreatedAt,
+			User: response.UserComment{
+				Id:       author.Id,
+				Email:    author.Email,
+				Username: author.Username,
+			},
+		})
+	}
+
+	return mentionResponses, helper.EncodeCursor(next), nil
+}
+
+func (usecase *UserUsecaseImpl) SearchUsers(ctx context.Context, request request.Search) ([]response.SearchUser, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(usecase.Timeout)*time.Second)
+	defer cancel()
+
+	err := usecase.Validate.Struct(request)
+	if err != nil {
+		return []response.SearchUser{}, "", err
+	}
+
+	page, err := helper.NewPage(request.Page, helper.DefaultPageLimit)
+	if err != nil {
+		return []response.SearchUser{}, "", err
+	}
+
+	users, next, err := usecase.Repository.SearchUsers(ctx, request.Query, page)
+	if err != nil {
+		return []response.SearchUser{}, "", err
+	}
+
+	userResponses := []response.SearchUser{}
+	for _, user := range users {
+		userResponses = append(userResponses, response.SearchUser{
+			Id:             user.Id,
+			Username:       user.Username,
+			Name:           user.Name,
+			ProfilePicture: user.ProfilePicture,
+		})
+	}
+
+	return userResponses, helper.EncodeCursor(next), nil
+}
+
+// checkUsernameReserved fails when another user gave up username less than UsernameCooldown ago.
+// It runs within the unit of work that claims username and keeps other users from claiming or
+// giving up username until that unit of work ends.
+func (usecase *UserUsecaseImpl) checkUsernameReserved(ctx context.Context, username string, userId uuid.UUID) error {
+	if usecase.UsernameCooldown <= 0 {
+		return nil
+	}
+
+	err := usecase.Repository.LockUsername(ctx, username)
+	if err != nil {
+		return err
+	}
+
+	since := time.Now().Add(-usecase.UsernameCooldown)
+	reserved, err := usecase.Repository.UsernameReserved(ctx, username, userId, since)
+	if err != nil {
+		return err
+	}
+	if reserved {
+		return repository.ErrUsernameReserved
+	}
+
+	return nil
+}
@@ -0,0 +1,154 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/dihanto/gosnap/internal/app/imaging"
+	"github.com/dihanto/gosnap/internal/app/repository"
+	"github.com/dihanto/gosnap/internal/app/storage"
+	"github.com/dihanto/gosnap/model/domain"
+)
+
+// resumeBatchSize is how many unprocessed photos are loaded per query when
+// the worker catches up on startup.
+const resumeBatchSize = 100
+
+// PhotoVariantWorker generates the thumbnail, medium and original variants of
+// uploaded photos in the background so posting a photo doesn't wait on image
+// processing. The queue lives in memory; photos that were still pending when
+// the process stopped are found again by resume on the next start.
+type PhotoVariantWorker struct {
+	Repository repository.PhotoRepository
+	Store      storage.BlobStore
+	Timeout    int
+	jobs       chan domain.Photo
+	// queued holds the ids of the photos in jobs or being processed, so a photo
+	// enqueued by both an upload and resume is only processed once.
+	queued sync.Map
+}
+
+func NewPhotoVariantWorker(repository repository.PhotoRepository, store storage.BlobStore, timeout int, queueSize int) *PhotoVariantWorker {
+	return &PhotoVariantWorker{
+		Repository: repository,
+		Store:      store,
+		Timeout:    timeout,
+		jobs:       make(chan domain.Photo, queueSize),
+	}
+}
+
+func (worker *PhotoVariantWorker) Start(concurrency int) {
+	for i := 0; i < concurrency; i++ {
+		go worker.run()
+	}
+	go worker.resume()
+}
+
+// Enqueue schedules variant generation without blocking the caller. When the
+// queue is full the photo is left for resume to pick up later.
+func (worker *PhotoVariantWorker) Enqueue(photo domain.Photo) {
+	if _, loaded := worker.queued.LoadOrStore(photo.Id, struct{}{}); loaded {
+		return
+	}
+
+	select {
+	case worker.jobs <- photo:
+	default:
+		worker.queued.Delete(photo.Id)
+		log.Printf("photo variant queue is full, photo %d will be processed on next start", photo.Id)
+	}
+}
+
+func (worker *PhotoVariantWorker) run() {
+	for photo := range worker.jobs {
+		err := worker.process(photo)
+		if err != nil {
+			log.Printf("generate variants for photo %d: %v", photo.Id, err)
+		}
+		worker.queued.Delete(photo.Id)
+	}
+}
+
+func (worker *PhotoVariantWorker) resume() {
+	afterId := 0
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(worker.Timeout)*time.Second)
+		photos, err := worker.Repository.GetPhotosWithoutVariants(ctx, afterId, resumeBatchSize)
+		cancel()
+		if err != nil {
+			log.Println(err)
+			return
+		}
+		if len(photos) == 0 {
+			return
+		}
+
+		for _, photo := range photos {
+			afterId = photo.Id
+			if _, loaded := worker.queued.LoadOrStore(photo.Id, struct{}{}); loaded {
+				continue
+			}
+			worker.jobs <- photo
+		}
+	}
+}
+
+func (worker *PhotoVariantWorker) process(photo domain.Photo) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(worker.Timeout)*time.Second)
+	defer cancel()
+
+	object, err := worker.Store.Get(ctx, photo.PhotoKey)
+	if err == storage.ErrObjectNotFound {
+		// an earlier run already replaced the upload by its variants
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	data, err := io.ReadAll(object)
+	object.Close()
+	if err != nil {
+		return err
+	}
+
+	renditions, err := imaging.Render(data, imaging.Variants)
+	if err != nil {
+		// retrying won't decode the upload either
+		errMark := worker.Repository.MarkPhotoVariantsFailed(ctx, photo.Id)
+		if errMark != nil {
+			log.Println(errMark)
+		}
+		return err
+	}
+
+	sourceKey := photo.PhotoKey
+	for _, rendition := range renditions {
+		key := imaging.VariantKey(sourceKey, rendition.Variant)
+		err = worker.Store.Put(ctx, key, bytes.NewReader(rendition.Data), int64(len(rendition.Data)), rendition.ContentType)
+		if err != nil {
+			return err
+		}
+
+		switch rendition.Variant {
+		case imaging.Thumbnail:
+			photo.ThumbnailKey = key
+		case imaging.Medium:
+			photo.MediumKey = key
+		case imaging.Original:
+			photo.PhotoKey = key
+		}
+	}
+
+	err = worker.Repository.UpdatePhotoVariants(ctx, photo)
+	if err != nil {
+		return err
+	}
+
+	// the upload as received still carries its EXIF metadata, only the
+	// stripped variants stay reachable
+	return worker.Store.Delete(ctx, sourceKey)
+}
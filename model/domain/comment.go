@@ -1,13 +1,23 @@
 package domain
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type Comment struct {
-	Id        int
-	UserId    uuid.UUID
-	PhotoId   int
-	Message   string
-	CreatedAt int32
-	UpdatedAt int32
-	DeletedAt int32
+	Id         int
+	UserId     uuid.UUID
+	PhotoId    int
+	ParentId   int
+	Depth      int
+	Message    string
+	Entities   []Entity
+	ReplyCount int
+	LikeCount  int
+	PinnedAt   *time.Time
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	DeletedAt  *time.Time
 }
@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	EntityMention = "mention"
+	EntityHashtag = "hashtag"
+)
+
+// Entity is a @username mention or #hashtag found in a caption or comment message.
+// Start and End count characters of the text, End excluded, and cover the @ or #.
+type Entity struct {
+	Type     string
+	Start    int
+	End      int
+	Tag      string
+	Username string
+	UserId   uuid.UUID
+}
+
+// Mention is a photo caption or comment message that mentions a user.
+type Mention struct {
+	PhotoId   int
+	CommentId int
+	Text      string
+	Entities  []Entity
+	UserId    uuid.UUID
+	CreatedAt time.Time
+}
+
+// Hashtag is a hashtag together with how many photos have it in their caption.
+type Hashtag struct {
+	Id         int
+	Name       string
+	PhotoCount int
+}
@@ -1,8 +1,15 @@
 package domain
 
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
 type Follow struct {
-	Id               int
-	FollowerCount    int
-	TargetUsername   string
-	FollowerUsername string
+	FollowerId     uuid.UUID
+	FolloweeId     uuid.UUID
+	TargetUsername string
+	FollowerCount  int
+	CreatedAt      time.Time
 }
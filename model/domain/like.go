@@ -1,11 +1,21 @@
 package domain
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type Like struct {
-	Id        int
 	LikeCount int
 	PhotoId   int
 	UserId    uuid.UUID
-	CreatedAt int32
+	CreatedAt time.Time
+}
+
+type CommentLike struct {
+	LikeCount int
+	CommentId int
+	UserId    uuid.UUID
+	CreatedAt time.Time
 }
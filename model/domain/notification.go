@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	NotificationLike    = "like"
+	NotificationComment = "comment"
+	NotificationFollow  = "follow"
+	NotificationMention = "mention"
+)
+
+// Notification tells UserId that ActorId liked or commented on their photo, followed them
+// or mentioned them. Until it is read, later events of the same Type about the same photo
+// or comment are coalesced into it: ActorCount counts the users behind them and ActorId is
+// the latest of them.
+type Notification struct {
+	Id         int
+	UserId     uuid.UUID
+	Type       string
+	PhotoId    int
+	CommentId  int
+	ActorId    uuid.UUID
+	ActorCount int
+	ReadAt     *time.Time
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// NotificationPreferences are the types of notification a user wants to get.
+type NotificationPreferences struct {
+	UserId   uuid.UUID
+	Likes    bool
+	Comments bool
+	Follows  bool
+	Mentions bool
+}
@@ -0,0 +1,40 @@
+package domain
+
+import "time"
+
+// Cursor is a keyset position in a list: the sort key of the last row that was
+// returned. Time holds the timestamp part of the key, in microseconds since the
+// Unix epoch, when a list is ordered by time, Rank the relevance of the row when
+// search results are ordered by it, and Key the unique tie breaker (an id or a
+// username).
+type Cursor struct {
+	Time int64   `json:"t,omitempty"`
+	Rank float64 `json:"r,omitempty"`
+	Key  string  `json:"k,omitempty"`
+}
+
+// NewTimeCursor returns the cursor of a row ordered by time and key.
+func NewTimeCursor(t time.Time, key string) Cursor {
+	return Cursor{Time: t.UnixMicro(), Key: key}
+}
+
+// NewRankCursor returns the cursor of a search result ordered by rank and key.
+func NewRankCursor(rank float64, key string) Cursor {
+	return Cursor{Rank: rank, Key: key}
+}
+
+func (cursor Cursor) IsZero() bool {
+	return cursor == Cursor{}
+}
+
+// Timestamp returns the timestamp part of the cursor.
+func (cursor Cursor) Timestamp() time.Time {
+	return time.UnixMicro(cursor.Time)
+}
+
+// Page asks a repository for at most Limit rows after Cursor. A zero Cursor
+// starts at the beginning of the list.
+type Page struct {
+	Cursor Cursor
+	Limit  int
+}
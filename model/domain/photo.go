@@ -1,14 +1,32 @@
 package domain
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type Photo struct {
-	Id          int
-	Title       string
-	Caption     string
-	PhotoBase64 string
-	UserId      uuid.UUID
-	CreatedAt   int32
-	UpdatedAt   int32
-	DeletedAt   int32
+	Id           int
+	Title        string
+	Caption      string
+	PhotoKey     string
+	ThumbnailKey string
+	MediumKey    string
+	Entities     []Entity
+	PhotoBase64  string
+	UserId       uuid.UUID
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	DeletedAt    *time.Time
+}
+
+// PhotoDetail is a photo together with its author, like state and a page of its comments.
+type PhotoDetail struct {
+	Photo      Photo
+	User       User
+	Like       Like
+	Liked      bool
+	Comments   []Comment
+	Commenters []User
 }
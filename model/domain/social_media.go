@@ -1,13 +1,17 @@
 package domain
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type SocialMedia struct {
 	Id             int
 	Name           string
 	SocialMediaUrl string
 	UserId         uuid.UUID
-	CreatedAt      int32
-	UpdatedAt      int32
-	DeletedAt      int32
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	DeletedAt      *time.Time
 }
@@ -1,6 +1,10 @@
 package domain
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type User struct {
 	Id             uuid.UUID
@@ -9,7 +13,11 @@ type User struct {
 	Email          string
 	Password       string
 	ProfilePicture string
-	CreatedAt      int32
-	UpdatedAt      int32
-	DeletedAt      int32
+	Role           string
+	FollowerCount  int
+	FollowingCount int
+	SuspendedAt    *time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	DeletedAt      *time.Time
 }
@@ -3,8 +3,9 @@ package request
 import "github.com/google/uuid"
 
 type Comment struct {
-	Id      int       `json:"id"`
-	UserId  uuid.UUID `json:"userId"`
-	PhotoId int       `json:"photoId"`
-	Message string    `validate:"required" json:"message"`
+	Id       int       `json:"id"`
+	UserId   uuid.UUID `json:"userId"`
+	PhotoId  int       `json:"photoId"`
+	ParentId int       `validate:"min=0" json:"parentId"`
+	Message  string    `validate:"required" json:"message"`
 }
@@ -1,6 +1,8 @@
 package request
 
+import "github.com/google/uuid"
+
 type Follow struct {
-	FollowerUsername string `json:"FollowerUsername" validate:"required"`
-	TargetUsername   string `json:"TargetUsername" validate:"required"`
+	FollowerId     uuid.UUID `json:"followerId" validate:"required"`
+	TargetUsername string    `json:"targetUsername" validate:"required"`
 }
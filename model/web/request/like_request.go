@@ -6,3 +6,8 @@ type Like struct {
 	PhotoId int       `json:"photoId" validate:"required"`
 	UserId  uuid.UUID `json:"userId" validate:"required"`
 }
+
+type CommentLike struct {
+	CommentId int       `json:"commentId" validate:"required"`
+	UserId    uuid.UUID `json:"userId" validate:"required"`
+}
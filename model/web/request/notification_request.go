@@ -0,0 +1,23 @@
+package request
+
+import "github.com/google/uuid"
+
+type Notifications struct {
+	UserId uuid.UUID `json:"userId" validate:"required"`
+	Page   Page      `json:"page"`
+}
+
+// NotificationRead marks the notifications Ids as read, or every notification when Ids is empty.
+type NotificationRead struct {
+	UserId uuid.UUID `json:"-" validate:"required"`
+	Ids    []int     `json:"ids" validate:"max=100,dive,min=1"`
+}
+
+// NotificationPreferences turns types of notification on or off. Types left out keep their setting.
+type NotificationPreferences struct {
+	UserId   uuid.UUID `json:"-" validate:"required"`
+	Likes    *bool     `json:"likes"`
+	Comments *bool     `json:"comments"`
+	Follows  *bool     `json:"follows"`
+	Mentions *bool     `json:"mentions"`
+}
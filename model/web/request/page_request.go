@@ -0,0 +1,6 @@
+package request
+
+type Page struct {
+	Cursor string `json:"cursor"`
+	Limit  int    `json:"limit" validate:"min=0,max=100"`
+}
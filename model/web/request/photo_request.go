@@ -1,6 +1,10 @@
 package request
 
-import "github.com/google/uuid"
+import (
+	"io"
+
+	"github.com/google/uuid"
+)
 
 type Photo struct {
 	Id          int       `json:"id"`
@@ -9,3 +13,27 @@ type Photo struct {
 	PhotoBase64 string    `json:"photoBase64"`
 	UserId      uuid.UUID `json:"userId"`
 }
+
+type PhotoUpload struct {
+	Title   string        `json:"title" validate:"required,max=100"`
+	Caption string        `json:"caption" validate:"max=100"`
+	UserId  uuid.UUID     `json:"userId"`
+	Photo   io.ReadSeeker `json:"-" validate:"required"`
+	Size    int64         `json:"-"`
+}
+
+type Feed struct {
+	UserId uuid.UUID `json:"userId" validate:"required"`
+	Page   Page      `json:"page"`
+}
+
+type TagPhotos struct {
+	Tag  string `json:"tag" validate:"required,max=100"`
+	Page Page   `json:"page"`
+}
+
+type PhotoDetail struct {
+	Id     int       `json:"id" validate:"required"`
+	UserId uuid.UUID `json:"userId" validate:"required"`
+	Page   Page      `json:"page"`
+}
@@ -0,0 +1,7 @@
+package request
+
+type Search struct {
+	Query string `json:"q" validate:"required,max=100"`
+	Type  string `json:"type" validate:"required,oneof=users photos tags"`
+	Page  Page   `json:"page"`
+}
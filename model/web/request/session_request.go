@@ -0,0 +1,5 @@
+package request
+
+type RefreshToken struct {
+	RefreshToken string `validate:"required" json:"refreshToken"`
+}
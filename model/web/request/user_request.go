@@ -19,3 +19,13 @@ type UserUpdate struct {
 	Email          string    `json:"email"`
 	ProfilePicture string    `json:"profilePicture"`
 }
+
+type UserRole struct {
+	Id   uuid.UUID `json:"-" validate:"required"`
+	Role string    `json:"role" validate:"required,oneof=user moderator admin"`
+}
+
+type Mentions struct {
+	Username string `json:"username" validate:"required"`
+	Page     Page   `json:"page"`
+}
@@ -10,7 +10,9 @@ type PostComment struct {
 	Id        int       `json:"id"`
 	UserId    uuid.UUID `json:"userId"`
 	PhotoId   int       `json:"photoId"`
+	ParentId  int       `json:"parentId,omitempty"`
 	Message   string    `json:"message"`
+	Entities  []Entity  `json:"entities"`
 	CreatedAt time.Time `json:"createdAt"`
 }
 type UpdateComment struct {
@@ -18,29 +20,49 @@ type UpdateComment struct {
 	UserId    uuid.UUID `json:"userId"`
 	PhotoId   int       `json:"photoId"`
 	Message   string    `json:"message"`
+	Entities  []Entity  `json:"entities"`
 	UpdatedAt time.Time `json:"updatedAt"`
 }
 
 type GetComment struct {
 	Id        int       `json:"id"`
 	Message   string    `json:"message"`
+	Entities  []Entity  `json:"entities"`
 	UserId    uuid.UUID `json:"userId"`
 	PhotoId   int       `json:"photoId"`
+	ParentId  int       `json:"parentId,omitempty"`
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
 	User      UserComment
 	Photo     PhotoComment
 }
 
+// ThreadComment is a comment listed under its photo or under the comment it replies to.
+type ThreadComment struct {
+	Id         int         `json:"id"`
+	Message    string      `json:"message"`
+	Entities   []Entity    `json:"entities"`
+	UserId     uuid.UUID   `json:"userId"`
+	PhotoId    int         `json:"photoId"`
+	ParentId   int         `json:"parentId,omitempty"`
+	Depth      int         `json:"depth"`
+	ReplyCount int         `json:"replyCount"`
+	LikeCount  int         `json:"likeCount"`
+	Pinned     bool        `json:"pinned"`
+	CreatedAt  time.Time   `json:"createdAt"`
+	UpdatedAt  time.Time   `json:"updatedAt"`
+	User       UserComment `json:"user"`
+}
+
 type UserComment struct {
 	Id       uuid.UUID `json:"id"`
 	Email    string    `json:"email"`
 	Username string    `json:"username"`
 }
 type PhotoComment struct {
-	Id          int       `json:"id"`
-	Title       string    `json:"title"`
-	Caption     string    `json:"caption"`
-	PhotoBase64 string    `json:"photoBase64"`
-	UserId      uuid.UUID `json:"UserId"`
+	Id       int       `json:"id"`
+	Title    string    `json:"title"`
+	Caption  string    `json:"caption"`
+	PhotoUrl string    `json:"photoUrl"`
+	UserId   uuid.UUID `json:"UserId"`
 }
@@ -0,0 +1,29 @@
+package response
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Entity is a mention or hashtag in a caption or comment message. Start and End count
+// characters of the text, End excluded, and cover the @ or #.
+type Entity struct {
+	Type     string     `json:"type"`
+	Start    int        `json:"start"`
+	End      int        `json:"end"`
+	Tag      string     `json:"tag,omitempty"`
+	Username string     `json:"username,omitempty"`
+	UserId   *uuid.UUID `json:"userId,omitempty"`
+}
+
+// Mention is a photo caption, when CommentId is left out, or a comment that mentions a user.
+type Mention struct {
+	PhotoId   int         `json:"photoId"`
+	CommentId int         `json:"commentId,omitempty"`
+	Text      string      `json:"text"`
+	Entities  []Entity    `json:"entities"`
+	UserId    uuid.UUID   `json:"userId"`
+	CreatedAt time.Time   `json:"createdAt"`
+	User      UserComment `json:"user"`
+}
@@ -17,3 +17,15 @@ type Unlike struct {
 	PhotoId   int `json:"photoId"`
 	LikeCount int `json:"likeCount"`
 }
+
+type CommentLike struct {
+	CommentId int       `json:"commentId"`
+	UserId    uuid.UUID `json:"userId"`
+	LikeCount int       `json:"likeCount"`
+	LikedAt   time.Time `json:"likedAt"`
+}
+
+type CommentUnlike struct {
+	CommentId int `json:"commentId"`
+	LikeCount int `json:"likeCount"`
+}
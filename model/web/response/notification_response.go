@@ -0,0 +1,40 @@
+package response
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Notifications struct {
+	UnreadCount   int            `json:"unreadCount"`
+	Notifications []Notification `json:"notifications"`
+}
+
+// Notification is one or more events of the same type about the same photo or comment.
+// Actor is the user behind the latest of them and ActorCount counts them all.
+type Notification struct {
+	Id         int               `json:"id"`
+	Type       string            `json:"type"`
+	Message    string            `json:"message"`
+	PhotoId    int               `json:"photoId,omitempty"`
+	CommentId  int               `json:"commentId,omitempty"`
+	Actor      NotificationActor `json:"actor"`
+	ActorCount int               `json:"actorCount"`
+	Read       bool              `json:"read"`
+	CreatedAt  time.Time         `json:"createdAt"`
+	UpdatedAt  time.Time         `json:"updatedAt"`
+}
+
+type NotificationActor struct {
+	Id             uuid.UUID `json:"id"`
+	Username       string    `json:"username"`
+	ProfilePicture string    `json:"profilePicture"`
+}
+
+type NotificationPreferences struct {
+	Likes    bool `json:"likes"`
+	Comments bool `json:"comments"`
+	Follows  bool `json:"follows"`
+	Mentions bool `json:"mentions"`
+}
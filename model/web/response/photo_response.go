@@ -7,33 +7,82 @@ import (
 )
 
 type PostPhoto struct {
-	Id          int       `json:"id"`
-	Title       string    `json:"title"`
-	Caption     string    `json:"caption"`
-	PhotoBase64 string    `json:"photoBase64"`
-	UserId      uuid.UUID `json:"userId"`
-	CreatedAt   time.Time `json:"createdAt"`
+	Id           int       `json:"id"`
+	Title        string    `json:"title"`
+	Caption      string    `json:"caption"`
+	Entities     []Entity  `json:"entities"`
+	PhotoUrl     string    `json:"photoUrl"`
+	ThumbnailUrl string    `json:"thumbnailUrl"`
+	MediumUrl    string    `json:"mediumUrl"`
+	UserId       uuid.UUID `json:"userId"`
+	CreatedAt    time.Time `json:"createdAt"`
 }
 
 type UpdatePhoto struct {
-	Id          int       `json:"id"`
-	Caption     string    `json:"caption"`
-	PhotoBase64 string    `json:"photoBase64"`
-	UserId      uuid.UUID `json:"userId"`
-	CreatedAt   time.Time `json:"createdAt"`
-	UpdatedAt   time.Time `json:"updatedAt"`
+	Id           int       `json:"id"`
+	Caption      string    `json:"caption"`
+	Entities     []Entity  `json:"entities"`
+	PhotoUrl     string    `json:"photoUrl"`
+	ThumbnailUrl string    `json:"thumbnailUrl"`
+	MediumUrl    string    `json:"mediumUrl"`
+	UserId       uuid.UUID `json:"userId"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
 }
 
 type GetPhoto struct {
-	Id          int       `json:"id"`
-	Title       string    `json:"title"`
-	Caption     string    `json:"caption"`
-	PhotoBase64 string    `json:"photoBase64"`
-	UserId      uuid.UUID `json:"userId"`
-	CreatedAt   time.Time `json:"createdAt"`
-	UpdatedAt   time.Time `json:"updatedAt"`
-	User        User      `json:"user"`
-	Likes       Likes     `json:"like"`
+	Id           int       `json:"id"`
+	Title        string    `json:"title"`
+	Caption      string    `json:"caption"`
+	Entities     []Entity  `json:"entities"`
+	PhotoUrl     string    `json:"photoUrl"`
+	ThumbnailUrl string    `json:"thumbnailUrl"`
+	MediumUrl    string    `json:"mediumUrl"`
+	UserId       uuid.UUID `json:"userId"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+	User         User      `json:"user"`
+	Likes        Likes     `json:"like"`
+}
+
+type PhotoDetail struct {
+	Id           int                  `json:"id"`
+	Title        string               `json:"title"`
+	Caption      string               `json:"caption"`
+	Entities     []Entity             `json:"entities"`
+	PhotoUrl     string               `json:"photoUrl"`
+	ThumbnailUrl string               `json:"thumbnailUrl"`
+	MediumUrl    string               `json:"mediumUrl"`
+	UserId       uuid.UUID            `json:"userId"`
+	CreatedAt    time.Time            `json:"createdAt"`
+	UpdatedAt    time.Time            `json:"updatedAt"`
+	User         PhotoAuthor          `json:"user"`
+	Likes        PhotoLikes           `json:"like"`
+	Comments     []PhotoDetailComment `json:"comments"`
+}
+
+type PhotoAuthor struct {
+	Id             uuid.UUID `json:"id"`
+	Username       string    `json:"username"`
+	Name           string    `json:"name"`
+	ProfilePicture string    `json:"profilePicture"`
+}
+
+type PhotoLikes struct {
+	LikeCount int  `json:"likeCount"`
+	Liked     bool `json:"liked"`
+}
+
+type PhotoDetailComment struct {
+	Id         int         `json:"id"`
+	Message    string      `json:"message"`
+	Entities   []Entity    `json:"entities"`
+	UserId     uuid.UUID   `json:"userId"`
+	ReplyCount int         `json:"replyCount"`
+	LikeCount  int         `json:"likeCount"`
+	CreatedAt  time.Time   `json:"createdAt"`
+	UpdatedAt  time.Time   `json:"updatedAt"`
+	User       UserComment `json:"user"`
 }
 
 type Likes struct {
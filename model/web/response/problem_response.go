@@ -0,0 +1,23 @@
+package response
+
+// Problem is an RFC 7807 problem details body, sent as application/problem+json
+// for every failed request.
+type Problem struct {
+	Type      string            `json:"type"`
+	Title     string            `json:"title"`
+	Status    int               `json:"status"`
+	Detail    string            `json:"detail,omitempty"`
+	Instance  string            `json:"instance,omitempty"`
+	Code      string            `json:"code"`
+	RequestId string            `json:"request_id,omitempty"`
+	Errors    []ValidationError `json:"errors,omitempty"`
+}
+
+// ValidationError describes one invalid field of a request. Field is the JSON path
+// of the field and Rule the validation tag it failed, with its Param if any.
+type ValidationError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
@@ -0,0 +1,15 @@
+package response
+
+import "github.com/google/uuid"
+
+type SearchUser struct {
+	Id             uuid.UUID `json:"id"`
+	Username       string    `json:"username"`
+	Name           string    `json:"name"`
+	ProfilePicture string    `json:"profilePicture"`
+}
+
+type Tag struct {
+	Name       string `json:"name"`
+	PhotoCount int    `json:"photoCount"`
+}
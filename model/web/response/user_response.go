@@ -25,6 +25,16 @@ type FindUser struct {
 	Username       string `json:"username"`
 	Name           string `json:"name"`
 	ProfilePicture string `json:"profilePicture"`
+	Role           string `json:"role"`
+}
+
+type UserProfile struct {
+	Id             uuid.UUID `json:"id"`
+	Username       string    `json:"username"`
+	Name           string    `json:"name"`
+	ProfilePicture string    `json:"profilePicture"`
+	FollowerCount  int       `json:"followerCount"`
+	FollowingCount int       `json:"followingCount"`
 }
 
 type FindAllUser struct {
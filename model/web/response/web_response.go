@@ -1,7 +1,8 @@
 package response
 
 type WebResponse struct {
-	Status  int         `json:"status"`
-	Message string      `json:"message"`
-	Data    interface{} `json:"data"`
+	Status     int         `json:"status"`
+	Message    string      `json:"message"`
+	Data       interface{} `json:"data"`
+	NextCursor string      `json:"next_cursor,omitempty"`
 }